@@ -0,0 +1,142 @@
+// Package activity fans out the account activity notified by TransferTx to the streams watching it
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	// subscriberBuffer is how many events a subscriber can fall behind before it is dropped
+	subscriberBuffer     = 64
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	pingInterval         = 90 * time.Second
+)
+
+// Broker delivers the activity of every account to the subscribers of its owner
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan db.AccountActivity]bool
+	// closed is set on shutdown, the subscriptions made afterwards end right away
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[string]map[chan db.AccountActivity]bool)}
+}
+
+// Subscribe returns the activity of the accounts of the owner. The channel is closed when the subscriber falls
+// behind or the connection to Postgres is lost, events may be missing from then on so the caller has to resume
+// from the last one it got. It's also closed when the broker is. cancel releases the subscription
+func (b *Broker) Subscribe(owner string) (events <-chan db.AccountActivity, cancel func()) {
+	ch := make(chan db.AccountActivity, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[owner] == nil {
+		b.subscribers[owner] = make(map[chan db.AccountActivity]bool)
+	}
+	b.subscribers[owner][ch] = true
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(owner, ch)
+	}
+}
+
+// Publish sends the event to the subscribers of the owner without blocking, slow subscribers are dropped
+func (b *Broker) Publish(event db.AccountActivity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.Owner] {
+		select {
+		case ch <- event:
+		default:
+			b.drop(event.Owner, ch)
+		}
+	}
+}
+
+// Listen feeds the broker from the notifications on db.AccountActivityChannel until ctx is done
+func (b *Broker) Listen(ctx context.Context, dataSource string) error {
+	listener := pq.NewListener(dataSource, minReconnectInterval, maxReconnectInterval, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error().Err(err).Msg("account activity listener failed")
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(db.AccountActivityChannel); err != nil {
+		return fmt.Errorf("cannot listen to %s: %w", db.AccountActivityChannel, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.dropAll()
+			return nil
+		case notification := <-listener.Notify:
+			// pq sends nil after reconnecting, notifications may have been lost meanwhile
+			if notification == nil {
+				b.dropAll()
+				continue
+			}
+
+			var event db.AccountActivity
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Error().Err(err).Str("payload", notification.Extra).Msg("cannot decode account activity")
+				continue
+			}
+			b.Publish(event)
+		case <-time.After(pingInterval):
+			go listener.Ping()
+		}
+	}
+}
+
+// Close ends every subscription, so the streams return and the servers can stop without waiting for their clients
+func (b *Broker) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.dropAll()
+}
+
+func (b *Broker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for owner, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			b.drop(owner, ch)
+		}
+	}
+}
+
+// drop closes the subscription once, the caller holds the lock
+func (b *Broker) drop(owner string, ch chan db.AccountActivity) {
+	if !b.subscribers[owner][ch] {
+		return
+	}
+
+	close(ch)
+	delete(b.subscribers[owner], ch)
+	if len(b.subscribers[owner]) == 0 {
+		delete(b.subscribers, owner)
+	}
+}
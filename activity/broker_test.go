@@ -0,0 +1,78 @@
+package activity
+
+import (
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	broker := NewBroker()
+	owner := utils.RandomOwner()
+
+	events, cancel := broker.Subscribe(owner)
+	defer cancel()
+	others, cancelOthers := broker.Subscribe(utils.RandomOwner())
+	defer cancelOthers()
+
+	event := db.AccountActivity{EntryID: 1, AccountID: 1, Owner: owner, Amount: 10, Balance: 110}
+	broker.Publish(event)
+
+	require.Equal(t, event, <-events)
+	require.Empty(t, others)
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker()
+	owner := utils.RandomOwner()
+
+	events, cancel := broker.Subscribe(owner)
+	for i := 0; i <= subscriberBuffer; i++ {
+		broker.Publish(db.AccountActivity{EntryID: int64(i + 1), Owner: owner})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	require.Equal(t, subscriberBuffer, received)
+
+	// cancelling a dropped subscription is a no-op
+	cancel()
+}
+
+func TestBrokerCancel(t *testing.T) {
+	broker := NewBroker()
+	owner := utils.RandomOwner()
+
+	events, cancel := broker.Subscribe(owner)
+	cancel()
+	cancel()
+
+	_, ok := <-events
+	require.False(t, ok)
+	require.Empty(t, broker.subscribers)
+
+	broker.Publish(db.AccountActivity{EntryID: 1, Owner: owner})
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker()
+	owner := utils.RandomOwner()
+
+	events, cancel := broker.Subscribe(owner)
+	defer cancel()
+	broker.Close()
+
+	_, ok := <-events
+	require.False(t, ok)
+	require.Empty(t, broker.subscribers)
+
+	// the streams opened during the shutdown end too
+	late, cancelLate := broker.Subscribe(owner)
+	cancelLate()
+	_, ok = <-late
+	require.False(t, ok)
+	require.Empty(t, broker.subscribers)
+}
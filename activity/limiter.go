@@ -0,0 +1,39 @@
+package activity
+
+import "sync"
+
+// Limiter caps how many streams each user keeps open at the same time
+type Limiter struct {
+	mu   sync.Mutex
+	max  int
+	open map[string]int
+}
+
+// NewLimiter returns a Limiter allowing max streams per user, 0 means no limit
+func NewLimiter(max int) *Limiter {
+	return &Limiter{max: max, open: make(map[string]int)}
+}
+
+// Acquire returns false if the user already has the maximum of streams open, otherwise release closes the slot
+func (l *Limiter) Acquire(username string) (release func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.max > 0 && l.open[username] >= l.max {
+		return nil, false
+	}
+	l.open[username]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.open[username]--
+			if l.open[username] == 0 {
+				delete(l.open, username)
+			}
+		})
+	}, true
+}
@@ -0,0 +1,41 @@
+package activity
+
+import (
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(2)
+	username := utils.RandomOwner()
+
+	release1, ok := limiter.Acquire(username)
+	require.True(t, ok)
+	_, ok = limiter.Acquire(username)
+	require.True(t, ok)
+
+	_, ok = limiter.Acquire(username)
+	require.False(t, ok)
+
+	// other users have their own slots
+	_, ok = limiter.Acquire(utils.RandomOwner())
+	require.True(t, ok)
+
+	release1()
+	release1()
+	_, ok = limiter.Acquire(username)
+	require.True(t, ok)
+	_, ok = limiter.Acquire(username)
+	require.False(t, ok)
+}
+
+func TestLimiterWithoutMax(t *testing.T) {
+	limiter := NewLimiter(0)
+	username := utils.RandomOwner()
+
+	for i := 0; i < 10; i++ {
+		_, ok := limiter.Acquire(username)
+		require.True(t, ok)
+	}
+}
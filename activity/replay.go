@@ -0,0 +1,47 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+)
+
+// ReplayPageSize is how many entries each query of Replay reads
+const ReplayPageSize = 100
+
+// Replay sends the activity of the accounts of the owner after the cursor entry, oldest first.
+// It returns the entries sent, so the caller can skip them if they are also notified
+func Replay(ctx context.Context, store db.Querier, owner string, cursor int64, send func(event db.AccountActivity) error) (map[int64]bool, error) {
+	replayed := make(map[int64]bool)
+
+	for {
+		rows, err := store.ListAccountActivity(ctx, db.ListAccountActivityParams{
+			Owner: owner,
+			ID:    cursor,
+			Limit: ReplayPageSize,
+		})
+		if err != nil {
+			return replayed, fmt.Errorf("cannot list account activity: %w", err)
+		}
+
+		for _, row := range rows {
+			err = send(db.AccountActivity{
+				EntryID:   row.ID,
+				AccountID: row.AccountID,
+				Owner:     owner,
+				Amount:    row.Amount,
+				Balance:   row.Balance,
+				CreatedAt: row.CreatedAt.Time,
+			})
+			if err != nil {
+				return replayed, err
+			}
+			replayed[row.ID] = true
+			cursor = row.ID
+		}
+
+		if len(rows) < ReplayPageSize {
+			return replayed, nil
+		}
+	}
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/policy"
 	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/webhook"
 	"net/http"
 )
 
@@ -30,6 +33,8 @@ type (
 	}
 )
 
+var errAccountNotOwned = errors.New("owner doesn't belong to the authenticated user")
+
 func (s *Server) createAccount(ctx *gin.Context) {
 	var req createAccountReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -59,6 +64,7 @@ func (s *Server) createAccount(ctx *gin.Context) {
 		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 	} else {
+		s.publishEvent(ctx, account.Owner, webhook.EventAccountCreated, account)
 		ctx.JSON(http.StatusOK, account)
 	}
 }
@@ -77,15 +83,14 @@ func (s *Server) getAccount(ctx *gin.Context) {
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
-	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
-	if authPayload.UserName != account.Owner {
-		err = fmt.Errorf("owner doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+
+	if !s.authorizeAccount(ctx, policy.ViewAccount, account) {
 		return
-	} else {
-		ctx.JSON(http.StatusOK, account)
 	}
+
+	ctx.JSON(http.StatusOK, account)
 }
 
 // getAccountsList executes a paginated query
@@ -125,11 +130,10 @@ func (s *Server) deleteAccount(ctx *gin.Context) {
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
-	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
-	if authPayload.UserName != account.Owner {
-		err = fmt.Errorf("owner doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+
+	if !s.authorizeAccount(ctx, policy.DeleteAccount, account) {
 		return
 	}
 
@@ -140,3 +144,14 @@ func (s *Server) deleteAccount(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"account": req.ID})
 	}
 }
+
+// authorizeAccount answers 401 unless the policy lets the authenticated user perform the action on the account,
+// either as its owner or through its role
+func (s *Server) authorizeAccount(ctx *gin.Context, action policy.Action, account db.Account) bool {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	if err := policy.Authorize(authPayload, action, account.Owner); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errAccountNotOwned))
+		return false
+	}
+	return true
+}
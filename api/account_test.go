@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/webhook"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"net/http"
@@ -82,6 +83,24 @@ func TestGetAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "banker views any account",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, _authorizationTypeBearer, "banker", utils.BankerRole, time.Minute)
+			},
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+				validateResponseAccount(t, recorder.Body, account)
+			},
+		},
 		{
 			name:      "no authorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
@@ -182,6 +201,12 @@ func TestCreateAccountAPI(t *testing.T) {
 				})).
 					Times(1).
 					Return(account, nil)
+				store.EXPECT().ListWebhookSubscriptionsByEvent(gomock.Any(), gomock.Eq(db.ListWebhookSubscriptionsByEventParams{
+					Owner:     account.Owner,
+					EventType: webhook.EventAccountCreated,
+				})).
+					Times(1).
+					Return([]db.WebhookSubscription{}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				// check response
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/activity"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// activity events sent on the stream, incoming transfers are notified apart from the other balance updates
+const (
+	eventBalanceUpdated   = "balance.updated"
+	eventTransferReceived = "transfer.received"
+)
+
+const (
+	lastEventIDHeader                = "Last-Event-ID"
+	defaultActivityHeartbeatInterval = 15 * time.Second
+)
+
+var (
+	errTooManyStreams     = errors.New("too many activity streams open for the user")
+	errInvalidLastEventID = errors.New("last event id must be an entry id")
+)
+
+type activityEventResponse struct {
+	EntryID   int64     `json:"entry_id"`
+	AccountID int64     `json:"account_id"`
+	Amount    int64     `json:"amount"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// streamAccountActivity sends the activity of the accounts of the caller as Server-Sent Events. The id of every
+// event is its entry id, so browsers resume with Last-Event-ID when they reconnect.
+// The stream ends when the access token expires, and the credentials are checked again on every heartbeat
+func (s *Server) streamAccountActivity(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	cursor, err := lastEventID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	release, ok := s.activityStreams.Acquire(authPayload.UserName)
+	if !ok {
+		ctx.JSON(http.StatusTooManyRequests, errResponse(errTooManyStreams))
+		return
+	}
+	defer release()
+
+	// subscribing before the replay keeps the transfers committed meanwhile, the replayed ones are skipped
+	events, cancel := s.activity.Subscribe(authPayload.UserName)
+	defer cancel()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	var replayed map[int64]bool
+	if cursor > 0 {
+		replayed, err = activity.Replay(ctx.Request.Context(), s.store, authPayload.UserName, cursor, func(event db.AccountActivity) error {
+			return writeActivityEvent(ctx.Writer, event)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("user", authPayload.UserName).Msg("cannot replay account activity")
+			return
+		}
+	}
+
+	interval := s.config.ActivityHeartbeatInterval
+	if interval <= 0 {
+		interval = defaultActivityHeartbeatInterval
+	}
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	// API keys without expiration have no expiry, a nil channel never fires
+	var expired <-chan time.Time
+	if !authPayload.ExpiredAt.IsZero() {
+		expiry := time.NewTimer(time.Until(authPayload.ExpiredAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case <-heartbeat.C:
+			// the password was changed, the API key revoked or the consent of the OAuth app withdrawn
+			if err = s.reauthorize(ctx.Request.Context(), authPayload); err != nil {
+				log.Info().Err(err).Str("user", authPayload.UserName).Msg("account activity stream closed")
+				return
+			}
+			// comments keep proxies from closing idle connections
+			if _, err = io.WriteString(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case event, ok := <-events:
+			// the subscriber fell behind or notifications were lost, the browser reconnects with Last-Event-ID
+			if !ok {
+				return
+			}
+			if replayed[event.EntryID] {
+				continue
+			}
+			if err = writeActivityEvent(ctx.Writer, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reauthorize checks again the credentials of a stream that outlives the middleware: the access token must not
+// be older than the last password change, API keys must not be revoked and OAuth apps must keep the consent of the user
+func (s *Server) reauthorize(ctx context.Context, payload *token.Payload) error {
+	if payload.APIKeyID != 0 {
+		return s.apiKeys.Check(ctx, payload)
+	}
+
+	if err := s.passwordChanges.Check(ctx, payload); err != nil {
+		return err
+	}
+
+	if payload.Audience != "" {
+		return s.oauth.CheckGrant(ctx, payload)
+	}
+	return nil
+}
+
+// lastEventID returns the entry to resume after, from the header browsers send on reconnection
+// or the last_event_id query parameter for a new connection
+func lastEventID(ctx *gin.Context) (int64, error) {
+	value := ctx.GetHeader(lastEventIDHeader)
+	if value == "" {
+		value = ctx.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errInvalidLastEventID
+	}
+	return id, nil
+}
+
+func writeActivityEvent(w gin.ResponseWriter, event db.AccountActivity) error {
+	data, err := json.Marshal(activityEventResponse{
+		EntryID:   event.EntryID,
+		AccountID: event.AccountID,
+		Amount:    event.Amount,
+		Balance:   event.Balance,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	name := eventBalanceUpdated
+	if event.Amount > 0 {
+		name = eventTransferReceived
+	}
+
+	if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.EntryID, name, data); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// eventSourceAuthorization takes the access token from the access_token query parameter when there is no
+// authorization header, browsers can't set headers on EventSource connections
+func eventSourceAuthorization() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader(_authorizationHeaderKey) == "" {
+			if accessToken := ctx.Query("access_token"); accessToken != "" {
+				ctx.Request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationTypeBearer, accessToken))
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// CloseAccountActivity ends the activity streams, http.Server.Shutdown would wait for them otherwise.
+// Browsers reconnect to another instance with Last-Event-ID
+func (s *Server) CloseAccountActivity() {
+	s.activity.Close()
+}
+
+// ListenAccountActivity feeds the activity streams from the notifications of TransferTx until ctx is done
+func (s *Server) ListenAccountActivity(ctx context.Context) error {
+	return s.activity.Listen(ctx, s.config.SourceName)
+}
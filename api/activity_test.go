@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/activity"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamAccountActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	ts := httptest.NewServer(server.router)
+	defer ts.Close()
+
+	user, _ := randomUser()
+	store.EXPECT().
+		ListAccountActivity(gomock.Any(), gomock.Eq(db.ListAccountActivityParams{
+			Owner: user.Username,
+			ID:    4,
+			Limit: activity.ReplayPageSize,
+		})).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.ListAccountActivityParams) ([]db.ListAccountActivityRow, error) {
+			// a transfer commits during the replay, entry 6 is notified and also listed
+			server.activity.Publish(db.AccountActivity{EntryID: 6, AccountID: 1, Owner: user.Username, Amount: -20, Balance: 90})
+			server.activity.Publish(db.AccountActivity{EntryID: 7, AccountID: 1, Owner: user.Username, Amount: 30, Balance: 120})
+
+			now := sql.NullTime{Time: time.Now(), Valid: true}
+			return []db.ListAccountActivityRow{
+				{ID: 5, AccountID: 1, Amount: 10, Balance: 110, CreatedAt: now},
+				{ID: 6, AccountID: 1, Amount: -20, Balance: 90, CreatedAt: now},
+			}, nil
+		})
+
+	accessToken, _, err := server.token.CreateToken(user.Username, utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// EventSource can't set headers, the token goes in the query
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/activity?access_token=%s", ts.URL, accessToken), nil)
+	require.NoError(t, err)
+	request.Header.Set(lastEventIDHeader, "4")
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	var ids, names []string
+	scanner := bufio.NewScanner(response.Body)
+	for len(names) < 3 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			ids = append(ids, strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "event: "):
+			names = append(names, strings.TrimPrefix(line, "event: "))
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Equal(t, []string{"5", "6", "7"}, ids)
+	require.Equal(t, []string{eventTransferReceived, eventBalanceUpdated, eventTransferReceived}, names)
+}
+
+func TestStreamAccountActivityErrors(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name          string
+		setupRequest  func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "no authorization",
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "invalid last event id",
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+				request.Header.Set(lastEventIDHeader, "abc")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "too many streams",
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+				server.activityStreams = activity.NewLimiter(1)
+				_, ok := server.activityStreams.Acquire(user.Username)
+				require.True(t, ok)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "api key without the scope",
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				key, apiKey := randomApiKey(t, user.Username, apikey.ScopeWebhooksRead)
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+				addApiKeyAuthorization(request, key)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().ListAccountActivity(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/accounts/activity", nil)
+			require.NoError(t, err)
+
+			tc.setupRequest(t, request, server, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestStreamAccountActivityEnds(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name         string
+		buildStubs   func(store *mockdb.MockStore)
+		setupRequest func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore)
+	}{
+		{
+			name:       "access token expires",
+			buildStubs: func(store *mockdb.MockStore) {},
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, 100*time.Millisecond)
+			},
+		},
+		{
+			name: "password changed",
+			buildStubs: func(store *mockdb.MockStore) {
+				// the password changes after the stream is open
+				first := store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					After(first).
+					AnyTimes().
+					Return(time.Now().Add(time.Hour), nil)
+			},
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			},
+		},
+		{
+			name:       "server shuts down",
+			buildStubs: func(store *mockdb.MockStore) {},
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+				time.AfterFunc(50*time.Millisecond, server.CloseAccountActivity)
+			},
+		},
+		{
+			name:       "api key revoked",
+			buildStubs: func(store *mockdb.MockStore) {},
+			setupRequest: func(t *testing.T, request *http.Request, server *Server, store *mockdb.MockStore) {
+				key, apiKey := randomApiKey(t, user.Username, apikey.ScopeAccountsRead)
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+
+				revoked := apiKey
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetApiKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(revoked, nil)
+				addApiKeyAuthorization(request, key)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.ActivityHeartbeatInterval = 10 * time.Millisecond
+			recorder := httptest.NewRecorder()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, "/accounts/activity", nil)
+			require.NoError(t, err)
+
+			tc.setupRequest(t, request, server, store)
+			server.router.ServeHTTP(recorder, request)
+
+			// the stream was open and ended before the client went away
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.NoError(t, ctx.Err())
+		})
+	}
+}
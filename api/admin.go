@@ -0,0 +1,104 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"net/http"
+)
+
+type (
+	unlockUserReq struct {
+		UserName string `uri:"username" binding:"required,alphanum"`
+	}
+
+	updateUserRoleUri struct {
+		UserName string `uri:"username" binding:"required,alphanum"`
+	}
+
+	updateUserRoleReq struct {
+		Role string `json:"role" binding:"required,role"`
+	}
+
+	freezeAccountReq struct {
+		ID int64 `uri:"id" binding:"required,min=1"`
+	}
+)
+
+// unlockUser clears the failed logins of a user locked out by the brute-force protection
+func (s *Server) unlockUser(ctx *gin.Context) {
+	var req unlockUserReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if err := s.lockout.Unlock(ctx, req.UserName); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
+}
+
+// updateUserRole changes the role of a user, it's applied to the tokens issued from the next login or renewal
+func (s *Server) updateUserRole(ctx *gin.Context) {
+	var uri updateUserRoleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req updateUserRoleReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	user, err := s.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.UserName,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parseUserInfo(user))
+}
+
+// freezeAccount blocks every transfer from or to the account until it's unfrozen
+func (s *Server) freezeAccount(ctx *gin.Context) {
+	s.setAccountFrozen(ctx, true)
+}
+
+func (s *Server) unfreezeAccount(ctx *gin.Context) {
+	s.setAccountFrozen(ctx, false)
+}
+
+func (s *Server) setAccountFrozen(ctx *gin.Context, frozen bool) {
+	var req freezeAccountReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	account, err := s.store.SetAccountFrozen(ctx, db.SetAccountFrozenParams{
+		ID:       req.ID,
+		IsFrozen: frozen,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+	"time"
+)
+
+type (
+	createApiKeyReq struct {
+		Name   string   `json:"name" binding:"required"`
+		Scopes []string `json:"scopes" binding:"required,min=1,dive,api_key_scope"`
+		// ExpiresAt is optional, keys without it are valid until revoked
+		ExpiresAt *time.Time `json:"expires_at"`
+		// Username lets admins create keys for other users, it defaults to the authenticated user
+		Username string `json:"username" binding:"omitempty,alphanum"`
+	}
+
+	// apiKeyRsp never includes the key or its hash
+	apiKeyRsp struct {
+		ID         int64      `json:"id"`
+		Username   string     `json:"username"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	createApiKeyRsp struct {
+		// Key is only returned once, when it's created
+		Key    string    `json:"key"`
+		ApiKey apiKeyRsp `json:"api_key"`
+	}
+
+	apiKeyURI struct {
+		ID int64 `uri:"id" binding:"required,min=1"`
+	}
+)
+
+func parseApiKey(apiKey db.ApiKey) apiKeyRsp {
+	return apiKeyRsp{
+		ID:         apiKey.ID,
+		Username:   apiKey.Username,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  nullTime(apiKey.ExpiresAt),
+		LastUsedAt: nullTime(apiKey.LastUsedAt),
+		RevokedAt:  nullTime(apiKey.RevokedAt),
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// createApiKey issues a scoped API key, users create keys for themselves and admins for anyone
+func (s *Server) createApiKey(ctx *gin.Context) {
+	var req createApiKeyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	username := req.Username
+	if username == "" {
+		username = authPayload.UserName
+	}
+	if err := policy.Authorize(authPayload, policy.ManageApiKeys, username); err != nil {
+		ctx.JSON(http.StatusForbidden, errResponse(err))
+		return
+	}
+
+	key, prefix, hashedKey, err := apikey.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	arg := db.CreateApiKeyParams{
+		Username:  username,
+		Name:      req.Name,
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    req.Scopes,
+	}
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	apiKey, err := s.store.CreateApiKey(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createApiKeyRsp{
+		Key:    key,
+		ApiKey: parseApiKey(apiKey),
+	})
+}
+
+// listApiKeys returns every key of the user, revoked and expired ones included
+func (s *Server) listApiKeys(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	apiKeys, err := s.store.ListApiKeys(ctx, authPayload.UserName)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	rsp := make([]apiKeyRsp, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		rsp = append(rsp, parseApiKey(apiKey))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// revokeApiKey disables a key right away, owners revoke their keys and admins any key
+func (s *Server) revokeApiKey(ctx *gin.Context) {
+	var req apiKeyURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	apiKey, err := s.store.GetApiKey(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	if err = policy.Authorize(authPayload, policy.ManageApiKeys, apiKey.Username); err != nil {
+		// keys of other users are reported as not found
+		ctx.JSON(http.StatusNotFound, errResponse(sql.ErrNoRows))
+		return
+	}
+
+	apiKey, err = s.store.RevokeApiKey(ctx, apiKey.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parseApiKey(apiKey))
+}
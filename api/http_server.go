@@ -5,37 +5,101 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/micaelapucciariello/simplebank/activity"
+	"github.com/micaelapucciariello/simplebank/apikey"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/health"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/oauth"
+	"github.com/micaelapucciariello/simplebank/policy"
 	"github.com/micaelapucciariello/simplebank/token"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/webhook"
+	"net/http"
 )
 
+// gatewayPath matches every route of the gateway, they all start with /v1
+const gatewayPath = "/v1/*path"
+
 type Server struct {
-	store  db.Store
-	router *gin.Engine
-	token  token.Maker
-	config utils.Config
+	store    db.Store
+	router   *gin.Engine
+	token    token.Maker
+	config   utils.Config
+	webhooks *webhook.Publisher
+	mailer   mail.Mailer
+	// passwordChanges rejects tokens issued before the user changed its password
+	passwordChanges *token.PasswordChangeCache
+	mfa             *mfa.Service
+	lockout         *lockout.Guard
+	apiKeys         *apikey.Authenticator
+	oauth           *oauth.Provider
+	activity        *activity.Broker
+	activityStreams *activity.Limiter
 }
 
 func NewServer(config utils.Config, store db.Store) (server *Server, err error) {
-	router := gin.Default()
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	router := gin.New()
+	// X-Forwarded-For is set by the clients, the lockout and the sessions use the address of the connection
+	if err = router.SetTrustedProxies(nil); err != nil {
+		return nil, fmt.Errorf("cannot set trusted proxies: %w", err)
+	}
+	router.Use(loggerMiddleware(), metricsMiddleware(), gin.Recovery())
+	tokenMaker, err := token.NewMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token validator: %w", err)
 	}
 
+	mailer, err := mail.NewMailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+
+	guard := lockout.NewGuard(store, config)
 	server = &Server{
-		store:  store,
-		token:  tokenMaker,
-		config: config,
+		store:           store,
+		token:           tokenMaker,
+		config:          config,
+		webhooks:        webhook.NewPublisher(store),
+		mailer:          mailer,
+		passwordChanges: token.NewPasswordChangeCache(config.PasswordChangeCacheTTL, store.GetUserPasswordChangedAt),
+		mfa:             mfa.NewService(store, guard, config.MFAIssuer, config.MFAChallengeDuration),
+		lockout:         guard,
+		apiKeys:         apikey.NewAuthenticator(store),
+		oauth:           oauth.NewProvider(store, tokenMaker, config),
+		activity:        activity.NewBroker(),
+		activityStreams: activity.NewLimiter(config.ActivityStreamsPerUser),
 	}
 
-	// set currency validator
+	// set currency, webhook event and url, role, api key scope and redirect uri validators
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		err = v.RegisterValidation("currency", validCurrency)
 		if err != nil {
 			return nil, err
 		}
+		err = v.RegisterValidation("webhook_event", validWebhookEvent)
+		if err != nil {
+			return nil, err
+		}
+		err = v.RegisterValidation("webhook_url", validWebhookURL)
+		if err != nil {
+			return nil, err
+		}
+		err = v.RegisterValidation("role", validRole)
+		if err != nil {
+			return nil, err
+		}
+		err = v.RegisterValidation("api_key_scope", validApiKeyScope)
+		if err != nil {
+			return nil, err
+		}
+		err = v.RegisterValidation("redirect_uri", validRedirectURI)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	server.initRouter(router)
@@ -49,21 +113,80 @@ func (s *Server) Start(address string) error {
 	return s.router.Run(address)
 }
 
+// Handler returns the routes of the server, for callers serving them on their own listener
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// MountGateway serves the grpc-gateway under /v1 next to the routes of the API, so clients can move to the
+// gateway routes on the same port
+func (s *Server) MountGateway(gateway http.Handler) {
+	s.router.Any(gatewayPath, gin.WrapH(gateway))
+}
+
+// MountHealth serves the liveness and readiness probes of the checker
+func (s *Server) MountHealth(checker *health.Checker) {
+	s.router.GET(health.LivenessPath, gin.WrapF(checker.ServeLiveness))
+	s.router.GET(health.ReadinessPath, gin.WrapF(checker.ServeReadiness))
+}
+
 func (s *Server) initRouter(router *gin.Engine) {
 	// declares the api routes and its functions
 	router.POST("/users", s.createUser)
 	router.POST("/users/login", s.loginUser)
+	router.POST("/users/login/mfa", s.verifyLoginMfa)
+	router.GET("/users/verify_email", s.verifyEmail)
+	router.POST("/users/password/forgot", s.forgotPassword)
+	router.POST("/users/password/reset", s.resetPassword)
+	router.POST("/users/logout", s.logoutUser)
 	router.POST("/token/new", s.renewAccessToken)
+	router.POST("/oauth/token", s.oauthToken)
+	router.GET(metrics.Path, gin.WrapH(metrics.Handler()))
 
-	authRoutes := router.Group("/", authMiddleware(s.token))
+	authRoutes := router.Group("/", authMiddleware(s.token, s.passwordChanges, nil, nil))
 	authRoutes.GET("/users/:username", s.getUser)
+	authRoutes.POST("/users/verify_email/resend", s.resendVerifyEmail)
+	authRoutes.PATCH("/users/me/password", s.changePassword)
+	authRoutes.POST("/users/me/totp", s.enrollTotp)
+	authRoutes.POST("/users/me/totp/confirm", s.confirmTotp)
+	authRoutes.POST("/users/logout_all", s.logoutAllSessions)
+
+	authRoutes.GET("/sessions", s.listSessions)
+	authRoutes.DELETE("/sessions/:id", s.revokeSession)
+
+	authRoutes.POST("/api_keys", s.createApiKey)
+	authRoutes.GET("/api_keys", s.listApiKeys)
+	authRoutes.DELETE("/api_keys/:id", s.revokeApiKey)
+
+	authRoutes.POST("/oauth/clients", s.registerOauthClient)
+	authRoutes.GET("/oauth/authorize", s.oauthConsentScreen)
+	authRoutes.POST("/oauth/authorize", s.oauthAuthorize)
+	authRoutes.GET("/oauth/consents", s.listOauthConsents)
+	authRoutes.DELETE("/oauth/consents/:client_id", s.revokeOauthConsent)
+
+	authRoutes.POST("/admin/users/:username/unlock", permissionMiddleware(policy.ManageUsers), s.unlockUser)
+	authRoutes.PUT("/admin/users/:username/role", permissionMiddleware(policy.ManageUsers), s.updateUserRole)
+	authRoutes.POST("/admin/accounts/:id/freeze", permissionMiddleware(policy.FreezeAccount), s.freezeAccount)
+	authRoutes.POST("/admin/accounts/:id/unfreeze", permissionMiddleware(policy.FreezeAccount), s.unfreezeAccount)
+
+	// services and OAuth apps can call these routes with an API key or a token that has the scope of the route
+	scopedRoutes := router.Group("/", authMiddleware(s.token, s.passwordChanges, s.apiKeys, s.oauth))
+	scopedRoutes.POST("/accounts", scopeMiddleware(apikey.ScopeAccountsWrite), s.createAccount)
+	scopedRoutes.GET("/accounts/:id", scopeMiddleware(apikey.ScopeAccountsRead), s.getAccount)
+	scopedRoutes.GET("/accounts", scopeMiddleware(apikey.ScopeAccountsRead), s.getAccountsList)
+	scopedRoutes.DELETE("/accounts/:id", scopeMiddleware(apikey.ScopeAccountsWrite), s.deleteAccount)
+
+	scopedRoutes.POST("/transfers", scopeMiddleware(apikey.ScopeTransfersWrite), s.createTranfer)
 
-	authRoutes.POST("/accounts", s.createAccount)
-	authRoutes.GET("/accounts/:id", s.getAccount)
-	authRoutes.GET("/accounts", s.getAccountsList)
-	authRoutes.DELETE("/accounts/:id", s.deleteAccount)
+	scopedRoutes.POST("/webhooks", scopeMiddleware(apikey.ScopeWebhooksWrite), s.createWebhookSubscription)
+	scopedRoutes.GET("/webhooks", scopeMiddleware(apikey.ScopeWebhooksRead), s.listWebhookSubscriptions)
+	scopedRoutes.DELETE("/webhooks/:id", scopeMiddleware(apikey.ScopeWebhooksWrite), s.deleteWebhookSubscription)
+	scopedRoutes.GET("/webhooks/:id/deliveries", scopeMiddleware(apikey.ScopeWebhooksRead), s.listWebhookDeliveries)
+	scopedRoutes.POST("/webhooks/:id/deliveries/:delivery_id/replay", scopeMiddleware(apikey.ScopeWebhooksWrite), s.replayWebhookDelivery)
 
-	authRoutes.POST("/transfers", s.createTranfer)
+	// browsers open the activity stream with EventSource, which can't send the authorization header
+	streamRoutes := router.Group("/", eventSourceAuthorization(), authMiddleware(s.token, s.passwordChanges, s.apiKeys, s.oauth))
+	streamRoutes.GET("/accounts/activity", scopeMiddleware(apikey.ScopeAccountsRead), s.streamAccountActivity)
 }
 
 // errResponse returns a gin key-value error
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/micaelapucciariello/simplebank/token"
+	"time"
+)
+
+// loggerMiddleware logs every request with the user the auth middleware authenticated, if any
+func loggerMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestCtx, request := logging.NewRequest(ctx.Request.Context(), ctx.GetHeader(logging.RequestIDHeader))
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Header(logging.RequestIDHeader, request.ID)
+
+		ctx.Next()
+
+		if payload, ok := ctx.Get(authorizationHeaderKey); ok {
+			request.SetUser(payload.(*token.Payload).UserName)
+		}
+		logging.HTTPRequest(request, ctx.Request.Method, ctx.Request.URL.Path, ctx.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// a request id is generated when the client doesn't send one
+	requestID := recorder.Header().Get(logging.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	require.Equal(t, "http", fields["protocol"])
+	require.Equal(t, http.MethodGet, fields["method"])
+	require.Equal(t, request.URL.Path, fields["path"])
+	require.Equal(t, float64(http.StatusOK), fields["status_code"])
+	require.Equal(t, requestID, fields["request_id"])
+	require.Equal(t, user.Username, fields["user"])
+	require.Contains(t, fields, "duration")
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
@@ -12,18 +16,36 @@ import (
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := utils.Config{
-		TokenSymmetricKey: utils.RandomString(32),
-		TokenDuration:     time.Minute,
+		TokenSymmetricKey:    utils.RandomString(32),
+		TokenDuration:        time.Minute,
+		MFAIssuer:            "SimpleBank",
+		MFAChallengeDuration: time.Minute,
+		OAuthCodeDuration:    time.Minute,
+		OAuthTokenDuration:   time.Minute,
+		LoginMaxUserFailures: 5,
+		LoginMaxIPFailures:   20,
+		LoginFailureDelay:    time.Second,
+		MailerDriver:         "file",
+		MailOutputDir:        t.TempDir(),
 	}
 
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
 
+	// the auth middleware looks up password changes on every request, tests that care set their own expectation first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+
 	return server
 }
 
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
+	// the logger tests capture the request logs, the others don't need them
+	log.Logger = zerolog.Nop()
 
 	os.Exit(m.Run())
 }
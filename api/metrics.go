@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"time"
+)
+
+// ginServer labels the HTTP metrics of the Gin server
+const ginServer = "gin"
+
+// metricsMiddleware records the count and duration of every request by the route it matched.
+// The mounted gateway records its requests by its own routes
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		if ctx.FullPath() == gatewayPath {
+			return
+		}
+		metrics.ObserveHTTPRequest(ginServer, ctx.Request.Method, ctx.FullPath(), ctx.Writer.Status(), time.Since(start))
+	}
+}
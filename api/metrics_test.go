@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+
+	// the route is recorded instead of the path
+	series := `simplebank_http_requests_total{code="401",method="GET",route="/accounts/:id",server="gin"}`
+	before := scrapeMetric(t, server, series)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	require.Equal(t, before+1, scrapeMetric(t, server, series))
+}
+
+// scrapeMetric returns the value of the series served on the metrics route, 0 if it wasn't recorded yet
+func scrapeMetric(t *testing.T, server *Server, series string) float64 {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, metrics.Path, nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			f, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return f
+		}
+	}
+	return 0
+}
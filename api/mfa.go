@@ -0,0 +1,109 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+)
+
+type (
+	enrollTotpRsp struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+
+	confirmTotpReq struct {
+		Code string `json:"code" binding:"required,len=6,numeric"`
+	}
+
+	confirmTotpRsp struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	verifyLoginMfaReq struct {
+		MfaToken string `json:"mfa_token" binding:"required,len=64"`
+		// Code is a TOTP code or one of the recovery codes
+		Code string `json:"code" binding:"required"`
+	}
+)
+
+// enrollTotp creates the secret to add in an authenticator app, 2FA isn't enabled until it's confirmed
+func (s *Server) enrollTotp(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	secret, uri, err := s.mfa.Enroll(ctx, authPayload.UserName)
+	if err != nil {
+		if err == mfa.ErrAlreadyEnabled {
+			ctx.JSON(http.StatusConflict, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollTotpRsp{
+		Secret:     secret,
+		OtpauthURI: uri,
+	})
+}
+
+// confirmTotp enables 2FA with a first valid code, the recovery codes are only shown in this response
+func (s *Server) confirmTotp(ctx *gin.Context) {
+	var req confirmTotpReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	codes, err := s.mfa.Confirm(ctx, authPayload.UserName, req.Code)
+	if err != nil {
+		switch err {
+		case mfa.ErrNotEnrolled:
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+		case mfa.ErrAlreadyEnabled:
+			ctx.JSON(http.StatusConflict, errResponse(err))
+		case mfa.ErrInvalidCode:
+			ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTotpRsp{RecoveryCodes: codes})
+}
+
+// verifyLoginMfa completes a login of a user with 2FA enabled using the mfa token returned by loginUser
+func (s *Server) verifyLoginMfa(ctx *gin.Context) {
+	var req verifyLoginMfaReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	username, err := s.mfa.VerifyChallenge(ctx, req.MfaToken, req.Code)
+	if err != nil {
+		if locked, ok := err.(*mfa.LockedError); ok {
+			tooManyAttempts(ctx, err, locked.RetryAfter)
+			return
+		}
+		switch err {
+		case mfa.ErrInvalidChallenge, mfa.ErrInvalidCode, mfa.ErrNotEnrolled:
+			ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		}
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	s.createLoginSession(ctx, user)
+}
@@ -0,0 +1,895 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+)
+
+func TestEnrollTotpAPI(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path enroll totp",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().UpsertTotpCredential(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpsertTotpCredentialParams) (db.TotpCredential, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.Secret)
+						return db.TotpCredential{Username: arg.Username, Secret: arg.Secret}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTotpRsp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.OtpauthURI, "otpauth://totp/")
+				require.Contains(t, rsp.OtpauthURI, rsp.Secret)
+			},
+		},
+		{
+			name: "already enabled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().UpsertTotpCredential(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "no authorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().UpsertTotpCredential(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			// check request
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.token)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestConfirmTotpAPI(t *testing.T) {
+	user, _ := randomUser()
+	credential := randomTotpCredential(t, user.Username, false)
+	code, err := totp.GenerateCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path confirm totp",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(credential, nil)
+				store.EXPECT().ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ConfirmTotpTxParams) (db.ConfirmTotpTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.RecoveryCodeHashes, 10)
+						return db.ConfirmTotpTxResult{TotpCredential: credential}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTotpRsp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, 10)
+			},
+		},
+		{
+			name: "invalid code",
+			body: gin.H{"code": wrongTotpCode(code)},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				store.EXPECT().ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "not enrolled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "invalid code format",
+			body: gin.H{"code": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(data))
+			// check request
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestVerifyLoginMfaAPI(t *testing.T) {
+	user, _ := randomUser()
+	credential := randomTotpCredential(t, user.Username, true)
+	code, err := totp.GenerateCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+	step, _ := mfa.MatchCode(code, credential.Secret, time.Now())
+	mfaToken, err := utils.RandomSecret(32)
+	require.NoError(t, err)
+
+	challenge := db.MfaChallenge{
+		ID:        utils.RandomInt(1, 1000),
+		Username:  user.Username,
+		TokenHash: utils.HashSecret(mfaToken),
+		ExpiredAt: time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path totp code",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Eq(db.UseTotpStepParams{
+					Step:     step,
+					Username: user.Username,
+				})).
+					Times(1).
+					Return(credential, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectCodeSuccess(store, user.Username)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.Equal(t, user.Username, rsp.UserMetadata.UserName)
+			},
+		},
+		{
+			name: "happy path recovery code",
+			body: gin.H{"mfa_token": mfaToken, "code": "ABCDE-12345"},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().UseMfaRecoveryCode(gomock.Any(), gomock.Eq(db.UseMfaRecoveryCodeParams{
+					Username: user.Username,
+					CodeHash: mfa.HashRecoveryCode("abcde12345"),
+				})).
+					Times(1).
+					Return(db.MfaRecoveryCode{Username: user.Username}, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectCodeSuccess(store, user.Username)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "wrong code counts an attempt",
+			body: gin.H{"mfa_token": mfaToken, "code": wrongTotpCode(code)},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectCodeFailure(store, user.Username)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "replayed code",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				used := credential
+				used.LastUsedStep = step
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(used, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectCodeFailure(store, user.Username)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			// a concurrent request used the same code first
+			name: "code used concurrently",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
+				store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1).
+					Return(challenge, nil)
+				expectCodeFailure(store, user.Username)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			// the wrong codes of every mfa token count as failed logins
+			name: "locked out",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(credential, nil)
+				expectCodeLocked(store, user.Username)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "too many attempts",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				locked := challenge
+				locked.Attempts = mfa.MaxChallengeAttempts
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(locked, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "expired mfa token",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				expired := challenge
+				expired.ExpiredAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(expired, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "unknown mfa token",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(db.MfaChallenge{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "invalid mfa token",
+			body: gin.H{"mfa_token": "short", "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			// check request
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestVerifyLoginMfaLockoutAPI logs in with the password again after every wrong code, the wrong codes still add up
+// to a lockout of the user
+func TestVerifyLoginMfaLockoutAPI(t *testing.T) {
+	user, password := randomUser()
+	credential := randomTotpCredential(t, user.Username, true)
+	code, err := totp.GenerateCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// the login_failures table, a scope is locked once its count reaches the max failures
+	type failureKey struct{ scope, identifier string }
+	failures := map[failureKey]*db.LoginFailure{}
+	store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, arg db.RecordLoginAttemptParams) (db.LoginFailure, error) {
+			key := failureKey{arg.Scope, arg.Identifier}
+			failure, ok := failures[key]
+			if !ok {
+				failure = &db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier}
+				failures[key] = failure
+			}
+			if failure.LockedUntil.Valid {
+				return db.LoginFailure{}, sql.ErrNoRows
+			}
+			failure.FailedCount++
+			if arg.MaxFailures > 0 && failure.FailedCount >= arg.MaxFailures {
+				failure.LockedUntil = sql.NullTime{Time: arg.LockUntil, Valid: true}
+			}
+			return *failure, nil
+		})
+	store.EXPECT().ForgetLoginAttempt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, arg db.ForgetLoginAttemptParams) error {
+			if failure, ok := failures[failureKey{arg.Scope, arg.Identifier}]; ok {
+				failure.FailedCount--
+			}
+			return nil
+		})
+	store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, arg db.DeleteLoginFailureParams) error {
+			delete(failures, failureKey{arg.Scope, arg.Identifier})
+			return nil
+		})
+	store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, arg db.ListLoginFailuresParams) ([]db.LoginFailure, error) {
+			var locked []db.LoginFailure
+			for _, failure := range failures {
+				if failure.LockedUntil.Valid {
+					locked = append(locked, *failure)
+				}
+			}
+			return locked, nil
+		})
+	store.EXPECT().DelayLogin(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(nil)
+
+	challenges := map[string]db.MfaChallenge{}
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		Return(user, nil)
+	store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		Return(credential, nil)
+	store.EXPECT().CreateMfaChallenge(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, arg db.CreateMfaChallengeParams) (db.MfaChallenge, error) {
+			challenge := db.MfaChallenge{
+				ID:        int64(len(challenges) + 1),
+				Username:  arg.Username,
+				TokenHash: arg.TokenHash,
+				ExpiredAt: arg.ExpiredAt,
+			}
+			challenges[arg.TokenHash] = challenge
+			return challenge, nil
+		})
+	store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ interface{}, tokenHash string) (db.MfaChallenge, error) {
+			return challenges[tokenHash], nil
+		})
+	store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.MfaChallenge{}, nil)
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	post := func(url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the password login and the wrong code count 2 of the 5 failures of the user
+	for i := 0; i < 2; i++ {
+		recorder := post("/users/login", gin.H{"username": user.Username, "password": password})
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp loginMfaRequiredResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.True(t, rsp.MfaRequired)
+
+		recorder = post("/users/login/mfa", gin.H{"mfa_token": rsp.MfaToken, "code": wrongTotpCode(code)})
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	// the fifth attempt locks the user, so the code of its challenge is refused
+	recorder := post("/users/login", gin.H{"username": user.Username, "password": password})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp loginMfaRequiredResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+
+	recorder = post("/users/login/mfa", gin.H{"mfa_token": rsp.MfaToken, "code": code})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	recorder = post("/users/login", gin.H{"username": user.Username, "password": password})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestCreateTransferMfaThresholdAPI(t *testing.T) {
+	user, _ := randomUser()
+	credential := randomTotpCredential(t, user.Username, true)
+	code, err := totp.GenerateCode(credential.Secret, time.Now())
+	require.NoError(t, err)
+
+	const threshold = 100
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(user2.Username)
+	fromAccount.Currency = utils.USD
+	toAccount.Currency = utils.USD
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "valid code above threshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold + 1,
+				"currency":        utils.USD,
+				"totp_code":       code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(credential, nil)
+				expectCodeAttempt(store)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(credential, nil)
+				expectCodeSuccess(store, user.Username)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{FromAccountID: fromAccount, ToAccountID: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "wrong code above threshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold + 1,
+				"currency":        utils.USD,
+				"totp_code":       wrongTotpCode(code),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(credential, nil)
+				expectCodeAttempt(store)
+				expectCodeFailure(store, user.Username)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "locked out above threshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold + 1,
+				"currency":        utils.USD,
+				"totp_code":       code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(credential, nil)
+				expectCodeLocked(store, user.Username)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "2fa not enabled above threshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold + 1,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			// the code is checked last, a transfer failing the other checks doesn't use it up
+			name: "currency mismatch keeps the code",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold + 1,
+				"currency":        utils.EUR,
+				"totp_code":       code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "no code needed below threshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{FromAccountID: fromAccount, ToAccountID: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.config.MFATransferThreshold = threshold
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func randomTotpCredential(t *testing.T, username string, confirmed bool) db.TotpCredential {
+	secret, _, err := mfa.GenerateSecret("SimpleBank", username)
+	require.NoError(t, err)
+
+	return db.TotpCredential{
+		Username:    username,
+		Secret:      secret,
+		IsConfirmed: confirmed,
+	}
+}
+
+// expectCodeAttempt expects the code to be counted in the login lockout of the user
+func expectCodeAttempt(store *mockdb.MockStore) {
+	store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.LoginFailure{FailedCount: 1}, nil)
+}
+
+// expectCodeSuccess expects the login failures of the user to be cleared
+func expectCodeSuccess(store *mockdb.MockStore, username string) {
+	store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{
+		Scope:      lockout.ScopeUsername,
+		Identifier: username,
+	})).
+		Times(1).
+		Return(nil)
+}
+
+// expectCodeFailure expects the next attempt of the user to be delayed
+func expectCodeFailure(store *mockdb.MockStore, username string) {
+	store.EXPECT().DelayLogin(gomock.Any(), gomock.Eq(db.DelayLoginParams{
+		BaseDelaySeconds: 1,
+		Scope:            lockout.ScopeUsername,
+		Identifier:       username,
+	})).
+		Times(1).
+		Return(nil)
+}
+
+// expectCodeLocked expects the user to be locked out for a minute
+func expectCodeLocked(store *mockdb.MockStore, username string) {
+	store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.LoginFailure{}, sql.ErrNoRows)
+	store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Eq(db.ListLoginFailuresParams{Username: username})).
+		Times(1).
+		Return([]db.LoginFailure{{
+			Scope:       lockout.ScopeUsername,
+			Identifier:  username,
+			LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		}}, nil)
+}
+
+// wrongTotpCode returns a 6 digit code different from the valid one
+func wrongTotpCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
@@ -1,19 +1,32 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	"github.com/micaelapucciariello/simplebank/oauth"
+	"github.com/micaelapucciariello/simplebank/policy"
 	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/rs/zerolog/log"
 	"net/http"
 	"strings"
 )
 
 const _authorizationHeaderKey = "authorization"
 const _authorizationTypeBearer = "Bearer"
+const _authorizationTypeApiKey = "ApiKey"
 const authorizationHeaderKey = "authorization_payload"
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+var (
+	errApiKeyNotAccepted     = errors.New("api keys are not accepted on this route")
+	errOAuthTokenNotAccepted = errors.New("tokens of oauth apps are not accepted on this route")
+)
+
+// authMiddleware accepts Bearer access tokens of users. API keys are accepted too when apiKeys is set,
+// and the tokens of OAuth apps when grants is set
+func authMiddleware(tokenMaker token.Maker, passwordChanges *token.PasswordChangeCache, apiKeys *apikey.Authenticator, grants *oauth.Provider) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(_authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -30,6 +43,11 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		authorizationType := fields[0]
+		if authorizationType == _authorizationTypeApiKey {
+			authenticateApiKey(ctx, apiKeys, fields[1])
+			return
+		}
+
 		if authorizationType != _authorizationTypeBearer {
 			err := fmt.Errorf("invalid authorization type: %v", authorizationType)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
@@ -38,12 +56,106 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 
 		accessToken := fields[1]
 		payload, err := tokenMaker.VerifyToken(accessToken)
+		if err == nil {
+			err = payload.CheckType(token.AccessToken)
+		}
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
 			return
 		}
 
+		// tokens issued before a password change are no longer valid
+		if err = passwordChanges.Check(ctx, payload); err != nil {
+			if errors.Is(err, token.ErrTokenRevoked) || errors.Is(err, sql.ErrNoRows) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		if payload.Audience != "" {
+			authorizeOAuthToken(ctx, grants, payload)
+			return
+		}
+
 		ctx.Set(authorizationHeaderKey, payload)
 		ctx.Next()
 	}
 }
+
+// authenticateApiKey sets the payload of the API key, every use of a key is logged
+func authenticateApiKey(ctx *gin.Context, apiKeys *apikey.Authenticator, key string) {
+	if apiKeys == nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(errApiKeyNotAccepted))
+		return
+	}
+
+	apiKey, err := apiKeys.Authenticate(ctx, key)
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidKey) || errors.Is(err, apikey.ErrExpiredKey) || errors.Is(err, apikey.ErrRevokedKey) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	log.Info().
+		Str("api_key", apiKey.Prefix).
+		Str("api_key_name", apiKey.Name).
+		Str("user", apiKey.Username).
+		Str("method", ctx.Request.Method).
+		Str("path", ctx.FullPath()).
+		Msg("api key used")
+	ctx.Set(authorizationHeaderKey, apikey.NewPayload(apiKey))
+	ctx.Next()
+}
+
+// authorizeOAuthToken sets the payload of a token issued to an OAuth app while the user keeps its consent
+func authorizeOAuthToken(ctx *gin.Context, grants *oauth.Provider, payload *token.Payload) {
+	if grants == nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(errOAuthTokenNotAccepted))
+		return
+	}
+
+	if err := grants.CheckGrant(ctx, payload); err != nil {
+		if errors.Is(err, oauth.ErrGrantRevoked) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.Set(authorizationHeaderKey, payload)
+	ctx.Next()
+}
+
+// scopeMiddleware rejects API keys and OAuth tokens without the scope, access tokens of users always pass.
+// It must run after authMiddleware
+func scopeMiddleware(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+		if !payload.HasScope(scope) {
+			err := fmt.Errorf("credentials don't have the %s scope", scope)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// permissionMiddleware rejects the request unless the role of the token grants the action, it must run after authMiddleware
+func permissionMiddleware(action policy.Action) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+		if !policy.Allowed(payload.Role, action) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(policy.ErrForbidden))
+			return
+		}
+
+		ctx.Next()
+	}
+}
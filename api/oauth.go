@@ -0,0 +1,335 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/oauth"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	registerOauthClientReq struct {
+		Name         string   `json:"name" binding:"required"`
+		RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,redirect_uri"`
+		Scopes       []string `json:"scopes" binding:"required,min=1,dive,api_key_scope"`
+		// Confidential apps run on a server and get a secret, public apps can only use the authorization code grant
+		Confidential bool `json:"confidential"`
+	}
+
+	oauthClientRsp struct {
+		ClientID string `json:"client_id"`
+		// ClientSecret is only returned once, when a confidential app is registered
+		ClientSecret string    `json:"client_secret,omitempty"`
+		Name         string    `json:"name"`
+		RedirectURIs []string  `json:"redirect_uris"`
+		Scopes       []string  `json:"scopes"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	// oauthAuthorizeReq is the authorization request of RFC 6749, apps send it in the query of the consent screen
+	oauthAuthorizeReq struct {
+		ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+		ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+		RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+		Scope               string `form:"scope" json:"scope" binding:"required"`
+		State               string `form:"state" json:"state"`
+		CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+		CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	}
+
+	oauthApproveReq struct {
+		oauthAuthorizeReq
+		Approve bool `json:"approve"`
+	}
+
+	oauthConsentScreenRsp struct {
+		ClientID    string   `json:"client_id"`
+		ClientName  string   `json:"client_name"`
+		RedirectURI string   `json:"redirect_uri"`
+		Scopes      []string `json:"scopes"`
+		State       string   `json:"state"`
+		// Granted is true when the user already approved every scope to the app
+		Granted bool `json:"granted"`
+	}
+
+	oauthRedirectRsp struct {
+		RedirectURI string `json:"redirect_uri"`
+	}
+
+	oauthTokenReq struct {
+		GrantType    string `form:"grant_type" binding:"required"`
+		Code         string `form:"code"`
+		RedirectURI  string `form:"redirect_uri"`
+		CodeVerifier string `form:"code_verifier"`
+		ClientID     string `form:"client_id"`
+		ClientSecret string `form:"client_secret"`
+		Scope        string `form:"scope"`
+	}
+
+	oauthConsentRsp struct {
+		ClientID   string    `json:"client_id"`
+		ClientName string    `json:"client_name"`
+		Scopes     []string  `json:"scopes"`
+		GrantedAt  time.Time `json:"granted_at"`
+	}
+
+	oauthConsentURI struct {
+		ClientID string `uri:"client_id" binding:"required"`
+	}
+)
+
+func (req oauthAuthorizeReq) authorizationRequest() oauth.AuthorizationRequest {
+	return oauth.AuthorizationRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              strings.Fields(req.Scope),
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}
+}
+
+// oauthErrResponse returns the error response of RFC 6749
+func oauthErrResponse(err *oauth.Error) gin.H {
+	return gin.H{"error": err.Code, "error_description": err.Description}
+}
+
+// registerOauthClient registers a third-party app of the authenticated user
+func (s *Server) registerOauthClient(ctx *gin.Context) {
+	var req registerOauthClientReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	client, secret, err := s.oauth.RegisterClient(ctx, authPayload.UserName, req.Name, req.RedirectURIs, req.Scopes, req.Confidential)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, oauthClientRsp{
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		CreatedAt:    client.CreatedAt,
+	})
+}
+
+// oauthConsentScreen returns what the user is asked to approve, the front-end renders it as the consent screen
+func (s *Server) oauthConsentScreen(ctx *gin.Context) {
+	var req oauthAuthorizeReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authRequest := req.authorizationRequest()
+	client, ok := s.validateAuthorization(ctx, authRequest)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	consent, err := s.store.GetOauthConsent(ctx, db.GetOauthConsentParams{
+		Username: authPayload.UserName,
+		ClientID: client.ClientID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, oauthConsentScreenRsp{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: authRequest.RedirectURI,
+		Scopes:      authRequest.Scopes,
+		State:       authRequest.State,
+		Granted:     err == nil && !consent.RevokedAt.Valid && containsAll(consent.Scopes, authRequest.Scopes),
+	})
+}
+
+// oauthAuthorize records the decision of the user and returns where the front-end must send it back to the app
+func (s *Server) oauthAuthorize(ctx *gin.Context) {
+	var req oauthApproveReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authRequest := req.authorizationRequest()
+	if _, ok := s.validateAuthorization(ctx, authRequest); !ok {
+		return
+	}
+
+	if !req.Approve {
+		redirect, err := oauth.RedirectError(authRequest, &oauth.Error{Code: oauth.ErrorAccessDenied, Description: "the user denied the request"})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, oauthRedirectRsp{RedirectURI: redirect})
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	redirect, err := s.oauth.Approve(ctx, authPayload.UserName, authRequest)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, oauthRedirectRsp{RedirectURI: redirect})
+}
+
+// validateAuthorization writes the error response and returns false when the authorization request is invalid.
+// Errors of the client or the redirect URI are only shown to the user, the rest also carry the redirect URI
+// that reports them to the app
+func (s *Server) validateAuthorization(ctx *gin.Context, req oauth.AuthorizationRequest) (db.OauthClient, bool) {
+	client, err := s.oauth.ValidateClient(ctx, req.ClientID, req.RedirectURI)
+	if err != nil {
+		var oauthErr *oauth.Error
+		if errors.As(err, &oauthErr) {
+			ctx.JSON(http.StatusBadRequest, oauthErrResponse(oauthErr))
+			return db.OauthClient{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return db.OauthClient{}, false
+	}
+
+	if err = s.oauth.ValidateAuthorization(client, req); err != nil {
+		oauthErr := err.(*oauth.Error)
+		rsp := oauthErrResponse(oauthErr)
+		if redirect, redirectErr := oauth.RedirectError(req, oauthErr); redirectErr == nil {
+			rsp["redirect_uri"] = redirect
+		}
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return db.OauthClient{}, false
+	}
+
+	return client, true
+}
+
+// oauthToken is the token endpoint of RFC 6749, apps authenticate with HTTP Basic or with the form parameters
+func (s *Server) oauthToken(ctx *gin.Context) {
+	// tokens must never be cached
+	ctx.Header("Cache-Control", "no-store")
+
+	var req oauthTokenReq
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, oauthErrResponse(&oauth.Error{Code: oauth.ErrorInvalidRequest, Description: err.Error()}))
+		return
+	}
+
+	if clientID, clientSecret, ok := ctx.Request.BasicAuth(); ok {
+		req.ClientID = clientID
+		req.ClientSecret = clientSecret
+	}
+
+	var (
+		rsp *oauth.TokenResponse
+		err error
+	)
+	switch req.GrantType {
+	case oauth.GrantTypeAuthorizationCode:
+		rsp, err = s.oauth.ExchangeCode(ctx, req.ClientID, req.ClientSecret, req.Code, req.RedirectURI, req.CodeVerifier)
+	case oauth.GrantTypeClientCredentials:
+		rsp, err = s.oauth.ClientCredentials(ctx, req.ClientID, req.ClientSecret, strings.Fields(req.Scope))
+	default:
+		err = &oauth.Error{Code: oauth.ErrorUnsupportedGrantType, Description: "grant_type is not supported"}
+	}
+	if err != nil {
+		var oauthErr *oauth.Error
+		if !errors.As(err, &oauthErr) {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+		if oauthErr.Code == oauth.ErrorInvalidClient {
+			ctx.JSON(http.StatusUnauthorized, oauthErrResponse(oauthErr))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, oauthErrResponse(oauthErr))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listOauthConsents returns the apps the user granted access to
+func (s *Server) listOauthConsents(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	consents, err := s.store.ListOauthConsents(ctx, authPayload.UserName)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	rsp := make([]oauthConsentRsp, 0, len(consents))
+	for _, consent := range consents {
+		client, err := s.store.GetOauthClient(ctx, consent.ClientID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		rsp = append(rsp, oauthConsentRsp{
+			ClientID:   consent.ClientID,
+			ClientName: client.Name,
+			Scopes:     consent.Scopes,
+			GrantedAt:  consent.CreatedAt,
+		})
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// revokeOauthConsent removes the access of an app, the tokens it already has stop working right away
+func (s *Server) revokeOauthConsent(ctx *gin.Context) {
+	var req oauthConsentURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	_, err := s.store.RevokeOauthConsent(ctx, db.RevokeOauthConsentParams{
+		Username: authPayload.UserName,
+		ClientID: req.ClientID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "app access revoked"})
+}
+
+func containsAll(values []string, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, v := range values {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
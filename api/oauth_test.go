@@ -0,0 +1,585 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	"github.com/micaelapucciariello/simplebank/oauth"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+)
+
+const testRedirectURI = "https://budget.example.com/callback"
+
+func randomOauthClient(owner string, secret string) db.OauthClient {
+	client := db.OauthClient{
+		ID:           utils.RandomInt(1, 1000),
+		ClientID:     utils.RandomString(32),
+		Name:         "budget app",
+		Owner:        owner,
+		RedirectUris: []string{testRedirectURI},
+		Scopes:       []string{apikey.ScopeAccountsRead},
+		CreatedAt:    time.Now(),
+	}
+	if secret != "" {
+		client.HashedSecret = utils.HashSecret(secret)
+	}
+	return client
+}
+
+func TestRegisterOauthClientAPI(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path register confidential client",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{testRedirectURI},
+				"scopes":        []string{apikey.ScopeAccountsRead},
+				"confidential":  true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().CreateOauthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateOauthClientParams) (db.OauthClient, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.NotEmpty(t, arg.HashedSecret)
+						return db.OauthClient{ClientID: arg.ClientID, Name: arg.Name, Owner: arg.Owner, RedirectUris: arg.RedirectUris, Scopes: arg.Scopes}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthClientRsp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.ClientID)
+				require.NotEmpty(t, rsp.ClientSecret)
+			},
+		},
+		{
+			name: "happy path register public client",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{testRedirectURI},
+				"scopes":        []string{apikey.ScopeAccountsRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().CreateOauthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateOauthClientParams) (db.OauthClient, error) {
+						require.Empty(t, arg.HashedSecret)
+						return db.OauthClient{ClientID: arg.ClientID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "client_secret")
+			},
+		},
+		{
+			name: "invalid redirect uri",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{"not a url"},
+				"scopes":        []string{apikey.ScopeAccountsRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().CreateOauthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "javascript redirect uri",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{testRedirectURI, "javascript:alert(document.cookie)"},
+				"scopes":        []string{apikey.ScopeAccountsRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().CreateOauthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "unsupported scope",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{testRedirectURI},
+				"scopes":        []string{"everything"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().CreateOauthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(data))
+			// check request
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOauthAuthorizeAPI(t *testing.T) {
+	user, _ := randomUser()
+	client := randomOauthClient("developer", "")
+	verifier := utils.RandomString(64)
+
+	body := func(redirectURI string, approve bool) gin.H {
+		return gin.H{
+			"response_type":         oauth.ResponseTypeCode,
+			"client_id":             client.ClientID,
+			"redirect_uri":          redirectURI,
+			"scope":                 apikey.ScopeAccountsRead,
+			"state":                 "xyz",
+			"code_challenge":        oauth.CodeChallenge(verifier),
+			"code_challenge_method": oauth.CodeChallengeMethodS256,
+			"approve":               approve,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path approve",
+			body: body(testRedirectURI, true),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().UpsertOauthConsent(gomock.Any(), gomock.Eq(db.UpsertOauthConsentParams{
+					Username: user.Username,
+					ClientID: client.ClientID,
+					Scopes:   []string{apikey.ScopeAccountsRead},
+				})).Times(1).Return(db.OauthConsent{}, nil)
+				store.EXPECT().CreateOauthAuthorizationCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateOauthAuthorizationCodeParams) (db.OauthAuthorizationCode, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, oauth.CodeChallenge(verifier), arg.CodeChallenge)
+						return db.OauthAuthorizationCode{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthRedirectRsp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				u, err := url.Parse(rsp.RedirectURI)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(rsp.RedirectURI, testRedirectURI))
+				require.NotEmpty(t, u.Query().Get("code"))
+				require.Equal(t, "xyz", u.Query().Get("state"))
+			},
+		},
+		{
+			name: "user denies",
+			body: body(testRedirectURI, false),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().UpsertOauthConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOauthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthRedirectRsp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				u, err := url.Parse(rsp.RedirectURI)
+				require.NoError(t, err)
+				require.Equal(t, oauth.ErrorAccessDenied, u.Query().Get("error"))
+				require.Empty(t, u.Query().Get("code"))
+			},
+		},
+		{
+			name: "unregistered redirect uri",
+			body: body("https://evil.example.com/callback", true),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().UpsertOauthConsent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "redirect_uri\":")
+			},
+		},
+		{
+			name: "unknown client",
+			body: body(testRedirectURI, true),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+				store.EXPECT().UpsertOauthConsent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), oauth.ErrorInvalidClient)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/authorize", bytes.NewReader(data))
+			// check request
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOauthTokenAPI(t *testing.T) {
+	secret := utils.RandomString(32)
+	client := randomOauthClient("developer", secret)
+
+	testCases := []struct {
+		name          string
+		form          url.Values
+		setupAuth     func(request *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path client credentials",
+			form: url.Values{"grant_type": {oauth.GrantTypeClientCredentials}},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(client.ClientID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+				var rsp oauth.TokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.Equal(t, oauth.TokenTypeBearer, rsp.TokenType)
+				require.Equal(t, apikey.ScopeAccountsRead, rsp.Scope)
+			},
+		},
+		{
+			name: "wrong client secret",
+			form: url.Values{
+				"grant_type":    {oauth.GrantTypeClientCredentials},
+				"client_id":     {client.ClientID},
+				"client_secret": {"wrong"},
+			},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), oauth.ErrorInvalidClient)
+			},
+		},
+		{
+			name: "used authorization code",
+			form: url.Values{
+				"grant_type":    {oauth.GrantTypeAuthorizationCode},
+				"code":          {"code"},
+				"redirect_uri":  {testRedirectURI},
+				"code_verifier": {utils.RandomString(64)},
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(client.ClientID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().UseOauthAuthorizationCode(gomock.Any(), gomock.Eq(utils.HashSecret("code"))).
+					Times(1).
+					Return(db.OauthAuthorizationCode{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), oauth.ErrorInvalidGrant)
+			},
+		},
+		{
+			name:      "unsupported grant type",
+			form:      url.Values{"grant_type": {"password"}},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), oauth.ErrorUnsupportedGrantType)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.form.Encode()))
+			// check request
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			tc.setupAuth(request)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOauthTokenAuthorization(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	client := randomOauthClient("developer", "")
+
+	testCases := []struct {
+		name          string
+		url           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "consent granted",
+			url:  fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthConsent(gomock.Any(), gomock.Eq(db.GetOauthConsentParams{
+					Username: user.Username,
+					ClientID: client.ClientID,
+				})).Times(1).Return(db.OauthConsent{Username: user.Username, ClientID: client.ClientID}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "consent revoked",
+			url:  fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				revoked := db.OauthConsent{RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+				store.EXPECT().GetOauthConsent(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "scope not granted",
+			url:  "/webhooks?page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthConsent(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthConsent{}, nil)
+				store.EXPECT().ListWebhookSubscriptions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "route of users only",
+			url:  "/sessions",
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetOauthConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListActiveSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			accessToken, _, err := server.token.CreateScopedToken(user.Username, client.ClientID, []string{apikey.ScopeAccountsRead}, time.Minute)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			// check request
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationTypeBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRevokeOauthConsentAPI(t *testing.T) {
+	user, _ := randomUser()
+	clientID := utils.RandomString(32)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path revoke consent",
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().RevokeOauthConsent(gomock.Any(), gomock.Eq(db.RevokeOauthConsentParams{
+					Username: user.Username,
+					ClientID: clientID,
+				})).Times(1).Return(db.OauthConsent{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "consent not found",
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().RevokeOauthConsent(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthConsent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			request, err := http.NewRequest(http.MethodDelete, "/oauth/consents/"+clientID, nil)
+			// check request
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.token, _authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"net/http"
+	"time"
+)
+
+type (
+	forgotPasswordReq struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	changePasswordReq struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=6"`
+	}
+
+	resetPasswordReq struct {
+		Token    string `json:"token" binding:"required,len=64"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+)
+
+// forgotPasswordMessage is the only answer to a reset request, so the endpoint doesn't reveal which emails are registered
+const forgotPasswordMessage = "if the email is registered you will receive a link to reset your password"
+
+var (
+	errInvalidResetToken = errors.New("reset token is invalid, already used or expired")
+	errWrongPassword     = errors.New("current password is incorrect")
+)
+
+func (s *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	token, err := utils.RandomSecret(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = s.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: utils.HashSecret(token),
+		ExpiredAt: time.Now().Add(s.config.ResetPasswordDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// the email is sent in background so response times don't depend on the email being registered
+	subject, content := mail.NewResetPasswordEmail(s.config.ResetPasswordURL, user.FullName, token)
+	mail.SendInBackground(s.mailer, subject, content, []string{user.Email})
+
+	ctx.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+}
+
+func (s *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	result, err := s.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      utils.HashSecret(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	s.passwordChanges.Set(result.User.Username, result.User.PasswordChangedAt)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "password updated, please log in again"})
+}
+
+// changePassword requires the current password, afterwards every token and session of the user is revoked
+func (s *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.UserName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if err = utils.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errWrongPassword))
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	result, err := s.store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	s.passwordChanges.Set(result.User.Username, result.User.PasswordChangedAt)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "password updated, please log in again"})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+	"time"
+)
+
+type (
+	// sessionRsp never includes the refresh token of the session
+	sessionRsp struct {
+		ID        uuid.UUID `json:"id"`
+		UserAgent string    `json:"user_agent"`
+		ClientIP  string    `json:"client_ip"`
+		CreatedAt time.Time `json:"created_at"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	sessionURI struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	logoutUserReq struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+)
+
+func parseSession(session db.Session) sessionRsp {
+	return sessionRsp{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		CreatedAt: session.CreatedAt.Time,
+		ExpiresAt: session.ExpiresAt.Time,
+	}
+}
+
+// listSessions returns the sessions of the user that can still renew tokens
+func (s *Server) listSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	sessions, err := s.store.ListActiveSessions(ctx, authPayload.UserName)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	rsp := make([]sessionRsp, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, parseSession(session))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// revokeSession blocks one session of the user, its refresh token can't be used anymore
+func (s *Server) revokeSession(ctx *gin.Context) {
+	var req sessionURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	_, err := s.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       uuid.MustParse(req.ID),
+		Username: authPayload.UserName,
+	})
+	if err != nil {
+		// sessions of other users are reported as not found
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// logoutUser blocks the session of the refresh token
+func (s *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	payload, err := s.token.VerifyToken(req.RefreshToken)
+	if err == nil {
+		err = payload.CheckType(token.RefreshToken)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	_, err = s.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       payload.ID,
+		Username: payload.UserName,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// logoutAllSessions blocks every session of the user, access tokens already issued stay valid until they expire
+func (s *Server) logoutAllSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	if err := s.store.BlockUserSessions(ctx, authPayload.UserName); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out from every session"})
+}
@@ -2,23 +2,38 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
 	"net/http"
 	"time"
 )
 
 type (
 	renewAccessTokenRequest struct {
-		RefreshToken string `json:"refresh_token"`
+		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
 	renewAccessTokenResponse struct {
+		SessionID             uuid.UUID `json:"session_id"`
+		AccessToken           string    `json:"access_token"`
+		AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
 		RefreshToken          string    `json:"refresh_token"`
 		RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 	}
 )
 
+var (
+	errBlockedSession     = errors.New("session is blocked")
+	errExpiredSession     = errors.New("session has expired")
+	errMismatchedSession  = errors.New("session doesn't belong to the token user")
+	errReusedRefreshToken = errors.New("refresh token was already used, every session of its family was revoked")
+)
+
+// renewAccessToken rotates the refresh token: it's consumed and a new access and refresh token pair is issued in the same
+// session family. Presenting a consumed refresh token again blocks the whole family
 func (s *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -27,6 +42,9 @@ func (s *Server) renewAccessToken(ctx *gin.Context) {
 	}
 
 	payload, err := s.token.VerifyToken(req.RefreshToken)
+	if err == nil {
+		err = payload.CheckType(token.RefreshToken)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, errResponse(err))
 		return
@@ -42,32 +60,69 @@ func (s *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	if session.Username != payload.UserName {
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+	if session.IsBlocked {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errBlockedSession))
+		return
+	}
+
+	// sessions created before expires_at was stored have no expiration and can't be renewed either
+	if !session.ExpiresAt.Valid || time.Now().After(session.ExpiresAt.Time) {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errExpiredSession))
 		return
 	}
 
-	refreshToken, payload, err := s.token.CreateToken(payload.UserName, s.config.RefreshTokenDuration)
+	if session.Username != payload.UserName || session.RefreshToken != req.RefreshToken {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errMismatchedSession))
+		return
+	}
+
+	// the role may have changed since the login, it's read again instead of copied from the refresh token
+	user, err := s.store.GetUser(ctx, session.Username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
-	session, err = s.store.CreateSession(ctx, db.CreateSessionParams{
-		ID:           payload.ID,
-		Username:     payload.UserName,
-		RefreshToken: refreshToken,
-		UserAgent:    ctx.Request.UserAgent(),
-		ClientIp:     ctx.ClientIP(),
-		IsBlocked:    false,
-		ExpiresAt:    sql.NullTime{Time: payload.ExpiredAt},
+	accessToken, accessPayload, err := s.token.CreateToken(user.Username, user.Role, s.config.TokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	refreshToken, refreshPayload, err := s.token.CreateRefreshToken(user.Username, user.Role, s.config.RefreshTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	result, err := s.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     payload.UserName,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt, Valid: true},
+		},
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if result.Reused {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errReusedRefreshToken))
+		return
 	}
 
 	rsp := renewAccessTokenResponse{
+		SessionID:             result.Session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
 		RefreshToken:          refreshToken,
-		RefreshTokenExpiresAt: payload.ExpiredAt,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
@@ -2,9 +2,12 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/policy"
 	"github.com/micaelapucciariello/simplebank/token"
 	"net/http"
 )
@@ -15,9 +18,17 @@ type (
 		ToAccountID   int64  `json:"to_account_id" binding:"required"`
 		Amount        int64  `json:"amount" binding:"required,min=1"`
 		Currency      string `json:"currency" binding:"required,currency"`
+		// TotpCode is required for amounts above the configured MFA transfer threshold
+		TotpCode string `json:"totp_code"`
 	}
 )
 
+var (
+	errEmailNotVerified    = errors.New("email must be verified before making transfers")
+	errTransferMfaRequired = errors.New("two-factor authentication must be enabled for transfers above the threshold")
+	errAccountFrozen       = errors.New("account is frozen")
+)
+
 func (s *Server) createTranfer(ctx *gin.Context) {
 	var req createTransferReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -25,25 +36,44 @@ func (s *Server) createTranfer(ctx *gin.Context) {
 		return
 	}
 
-	account, isValidFromAccount := s.validAccountCurrency(ctx, req.FromAccountID, req.Currency)
 	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
-	if account.Owner != authPayload.UserName {
-		ctx.JSON(http.StatusUnauthorized, fmt.Errorf("from account doesn't belong to the authenticated user"))
+	if !s.hasVerifiedEmail(ctx, authPayload.UserName) {
+		return
+	}
+
+	fromAccount, isValidFromAccount := s.validAccountCurrency(ctx, req.FromAccountID, req.Currency)
+	if !isValidFromAccount || !s.authorizeAccount(ctx, policy.TransferFromAccount, fromAccount) {
+		return
+	}
+
+	toAccount, isValidToAccount := s.validAccountCurrency(ctx, req.ToAccountID, req.Currency)
+	if !isValidToAccount {
+		return
 	}
 
-	_, isValidToAccount := s.validAccountCurrency(ctx, req.ToAccountID, req.Currency)
+	if fromAccount.IsFrozen || toAccount.IsFrozen {
+		ctx.JSON(http.StatusForbidden, errResponse(errAccountFrozen))
+		return
+	}
 
-	if !isValidToAccount || !isValidFromAccount {
+	// the code is only accepted once, it's checked last so a transfer failing the other checks doesn't use it up
+	if !s.hasValidTransferCode(ctx, authPayload.UserName, req.Amount, req.TotpCode) {
 		return
 	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		AfterTransfer: s.webhooks.TransferEvents(ctx),
 	}
 
 	transfer, err := s.store.TransferTx(ctx, arg)
 	if err != nil {
+		if err == db.ErrInsufficientFunds {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	} else {
@@ -76,3 +106,47 @@ func (s *Server) validAccountCurrency(ctx *gin.Context, accountID int64, currenc
 
 	return account, true
 }
+
+// hasVerifiedEmail answers 403 to users that haven't confirmed their email yet, they can't send transfers until then
+func (s *Server) hasVerifiedEmail(ctx *gin.Context, username string) bool {
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return false
+	}
+
+	if !user.IsEmailVerified {
+		ctx.JSON(http.StatusForbidden, errResponse(errEmailNotVerified))
+		return false
+	}
+
+	return true
+}
+
+// hasValidTransferCode asks for a TOTP code when the amount is above the MFA transfer threshold.
+// Users without TOTP get a 403 since they can't send such amounts at all, a wrong code gets a 401 and a 429 once
+// the user tried too many
+func (s *Server) hasValidTransferCode(ctx *gin.Context, username string, amount int64, code string) bool {
+	if s.config.MFATransferThreshold <= 0 || amount <= s.config.MFATransferThreshold {
+		return true
+	}
+
+	err := s.mfa.VerifyCode(ctx, username, code)
+	if err != nil {
+		if locked, ok := err.(*mfa.LockedError); ok {
+			tooManyAttempts(ctx, err, locked.RetryAfter)
+			return false
+		}
+		switch err {
+		case mfa.ErrNotEnrolled:
+			ctx.JSON(http.StatusForbidden, errResponse(errTransferMfaRequired))
+		case mfa.ErrInvalidCode:
+			ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		}
+		return false
+	}
+
+	return true
+}
@@ -2,12 +2,20 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"github.com/micaelapucciariello/simplebank/token"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog/log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -20,9 +28,16 @@ type (
 	}
 
 	createUserRsp struct {
-		UserName string `json:"username"`
-		FullName string `json:"full_name"`
-		Email    string `json:"email"`
+		UserName        string `json:"username"`
+		FullName        string `json:"full_name"`
+		Email           string `json:"email"`
+		IsEmailVerified bool   `json:"is_email_verified"`
+		Role            string `json:"role"`
+	}
+
+	verifyEmailReq struct {
+		EmailID    int64  `form:"email_id" binding:"required,min=1"`
+		SecretCode string `form:"secret_code" binding:"required,len=64"`
 	}
 
 	getUserReq struct {
@@ -30,8 +45,8 @@ type (
 	}
 
 	loginUserRequest struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	loginUserResponse struct {
@@ -42,13 +57,29 @@ type (
 		AccessTokenExpiresAt  time.Time     `json:"access_token_expires_at"`
 		UserMetadata          createUserRsp `json:"user_metadata"`
 	}
+
+	// loginMfaRequiredResponse is returned instead of the tokens when the user has 2FA enabled
+	loginMfaRequiredResponse struct {
+		MfaRequired       bool      `json:"mfa_required"`
+		MfaToken          string    `json:"mfa_token"`
+		MfaTokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+	}
+)
+
+var (
+	errInvalidVerifyEmail = errors.New("verification code is invalid, already used or expired")
+	errEmailVerified      = errors.New("email is already verified")
+	// errInvalidCredentials is the only login error, so it doesn't reveal which usernames exist
+	errInvalidCredentials = errors.New("invalid username or password")
 )
 
 func parseUserInfo(user db.User) createUserRsp {
 	return createUserRsp{
-		UserName: user.Username,
-		FullName: user.FullName,
-		Email:    user.Email,
+		UserName:        user.Username,
+		FullName:        user.FullName,
+		Email:           user.Email,
+		IsEmailVerified: user.IsEmailVerified,
+		Role:            user.Role,
 	}
 }
 
@@ -56,35 +87,123 @@ func (s *Server) createUser(ctx *gin.Context) {
 	var req createUserReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	secretCode, err := utils.RandomSecret(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.UserName,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.UserName,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		VerifyEmailSecretCodeHash: utils.HashSecret(secretCode),
+		VerifyEmailExpiredAt:      time.Now().Add(s.config.VerifyEmailDuration),
 	}
 
-	user, err := s.store.CreateUser(ctx, arg)
+	result, err := s.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
 				ctx.JSON(http.StatusForbidden, errResponse(err))
+				return
 			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// the code is mailed once the user is committed, if the mail fails the user asks for another with resendVerifyEmail
+	subject, content := mail.NewVerifyEmail(s.config.VerifyEmailURL, result.User.FullName, result.VerifyEmail.ID, secretCode)
+	if err = s.mailer.SendEmail(ctx, subject, content, []string{result.User.Email}); err != nil {
+		log.Error().Err(err).Str("user", result.User.Username).Msg("cannot send verify email")
+	}
+
+	rsp := parseUserInfo(result.User)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// verifyEmail confirms the address with the code sent on signup, codes are single-use and expire
+func (s *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	result, err := s.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:        req.EmailID,
+		SecretCodeHash: utils.HashSecret(req.SecretCode),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errResponse(errInvalidVerifyEmail))
 			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parseUserInfo(result.User))
+}
 
+// resendVerifyEmail mails a new verification code to the authenticated user, for when the mail sent on signup
+// never arrived or expired
+func (s *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	user, err := s.store.GetUser(ctx, authPayload.UserName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-	} else {
-		rsp := parseUserInfo(user)
-		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusConflict, errResponse(errEmailVerified))
+		return
+	}
+
+	secretCode, err := utils.RandomSecret(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	verifyEmail, err := s.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: utils.HashSecret(secretCode),
+		ExpiredAt:      time.Now().Add(s.config.VerifyEmailDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
+
+	subject, content := mail.NewVerifyEmail(s.config.VerifyEmailURL, user.FullName, verifyEmail.ID, secretCode)
+	if err = s.mailer.SendEmail(ctx, subject, content, []string{user.Email}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "a new verification email was sent"})
 }
 
 func (s *Server) getUser(ctx *gin.Context) {
@@ -114,40 +233,114 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	retryAfter, err := s.lockout.Attempt(ctx, req.Username, ctx.ClientIP())
+	if err != nil {
+		if err == lockout.ErrLocked {
+			tooManyAttempts(ctx, err, retryAfter)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
 	user, err := s.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
+			utils.CheckDummyPassword(req.Password)
+			s.loginFailed(ctx, req.Username)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
 	err = utils.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		s.loginFailed(ctx, req.Username)
+		return
+	}
+
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
-	accessToken, accessPayload, err := s.token.CreateToken(req.Username, s.config.TokenDuration)
+	// the wrong codes count as failures of the user too, they're only cleared once the code is verified so logging
+	// in again doesn't reset them
+	unlockUsername := user.Username
+	if mfaEnabled {
+		unlockUsername = ""
+	}
+	if err = s.lockout.RecordSuccess(ctx, unlockUsername, ctx.ClientIP()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	metrics.LoginSucceeded()
+
+	// with 2FA enabled no token is issued until the code is verified on /users/login/mfa
+	if mfaEnabled {
+		mfaToken, expiresAt, err := s.mfa.CreateChallenge(ctx, user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, loginMfaRequiredResponse{
+			MfaRequired:       true,
+			MfaToken:          mfaToken,
+			MfaTokenExpiresAt: expiresAt,
+		})
+		return
+	}
+
+	s.createLoginSession(ctx, user)
+}
+
+// tooManyAttempts answers 429 with the time left until the user or the client can try again
+func tooManyAttempts(ctx *gin.Context, err error, retryAfter time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, errResponse(err))
+}
+
+// loginFailed delays the next attempt and answers with the same error whether the user exists or not
+func (s *Server) loginFailed(ctx *gin.Context, username string) {
+	metrics.LoginFailed()
+	if err := s.lockout.RecordFailure(ctx, username); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidCredentials))
+}
+
+// createLoginSession issues the access and refresh tokens once every login factor was checked
+func (s *Server) createLoginSession(ctx *gin.Context, user db.User) {
+	accessToken, accessPayload, err := s.token.CreateToken(user.Username, user.Role, s.config.TokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
-	refreshToken, refreshPayload, err := s.token.CreateToken(req.Username, s.config.RefreshTokenDuration)
+	refreshToken, refreshPayload, err := s.token.CreateRefreshToken(user.Username, user.Role, s.config.RefreshTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
 	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
-		Username:     req.Username,
+		Username:     user.Username,
 		RefreshToken: refreshToken,
 		UserAgent:    ctx.Request.UserAgent(),
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
-		ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt},
+		ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt, Valid: true},
+		FamilyID:     refreshPayload.ID,
 	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
 
 	rsp := loginUserResponse{
 		SessionID:             session.ID,
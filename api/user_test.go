@@ -2,11 +2,13 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/lockout"
 	"github.com/micaelapucciariello/simplebank/token"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
@@ -21,7 +23,7 @@ import (
 	"github.com/micaelapucciariello/simplebank/utils"
 )
 
-type eqCreateUserParamsMatcher struct {
+type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserParams
 	password string
 }
@@ -140,9 +142,9 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:          user.Email,
 					HashedPassword: user.HashedPassword,
 				}
-				store.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
 					Times(1).
-					Return(user, nil)
+					Return(db.CreateUserTxResult{User: user}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				// check response
@@ -164,9 +166,9 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:          user.Email,
 					HashedPassword: user.HashedPassword,
 				}
-				store.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				// check response
@@ -206,20 +208,56 @@ func TestCreateUserAPI(t *testing.T) {
 
 func TestLoginUserAPI(t *testing.T) {
 	user, password := randomUser()
+	const clientIP = "10.0.0.1"
+
+	countAttempts := func(store *mockdb.MockStore) {
+		store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ interface{}, arg db.RecordLoginAttemptParams) (db.LoginFailure, error) {
+				switch arg.Scope {
+				case lockout.ScopeUsername:
+					require.Equal(t, user.Username, arg.Identifier)
+				case lockout.ScopeClientIP:
+					require.Equal(t, clientIP, arg.Identifier)
+				default:
+					t.Fatalf("unexpected scope %s", arg.Scope)
+				}
+				return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedCount: 1}, nil
+			})
+	}
 
 	testCases := []struct {
 		name          string
-		body          gin.H
+		password      string
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
-			name: "happy path login user",
+			name:     "happy path login user",
+			password: password,
 			buildStubs: func(store *mockdb.MockStore) {
 				// build stubs
+				countAttempts(store)
 				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{
+					Scope:      lockout.ScopeUsername,
+					Identifier: user.Username,
+				})).
+					Times(1).
+					Return(nil)
+				// the attempt is taken back from the client IP
+				store.EXPECT().ForgetLoginAttempt(gomock.Any(), gomock.Eq(db.ForgetLoginAttemptParams{
+					MaxFailures: 20,
+					Scope:       lockout.ScopeClientIP,
+					Identifier:  clientIP,
+				})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{}, sql.ErrNoRows)
 				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
 			},
@@ -229,9 +267,154 @@ func TestLoginUserAPI(t *testing.T) {
 			},
 		},
 		{
-			name: "internal server error",
+			name:     "mfa required",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				countAttempts(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				// the failures of the user are cleared once the code is verified
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().ForgetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetTotpCredential(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpCredential{Username: user.Username, IsConfirmed: true}, nil)
+				store.EXPECT().CreateMfaChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MfaChallenge{Username: user.Username, ExpiredAt: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginMfaRequiredResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.MfaRequired)
+				require.Len(t, rsp.MfaToken, 64)
+			},
+		},
+		{
+			name:     "wrong password delays the next attempt",
+			password: "wrong_password",
 			buildStubs: func(store *mockdb.MockStore) {
 				// build stubs
+				countAttempts(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().DelayLogin(gomock.Any(), gomock.Eq(db.DelayLoginParams{
+					BaseDelaySeconds: 1,
+					Scope:            lockout.ScopeUsername,
+					Identifier:       user.Username,
+				})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().ForgetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name:     "unknown user gets the same response",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				countAttempts(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().DelayLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name:     "locked out",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ interface{}, arg db.RecordLoginAttemptParams) (db.LoginFailure, error) {
+						if arg.Scope == lockout.ScopeUsername {
+							return db.LoginFailure{}, sql.ErrNoRows
+						}
+						return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedCount: 1}, nil
+					})
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Eq(db.ListLoginFailuresParams{
+					Username: user.Username,
+					ClientIp: clientIP,
+				})).
+					Times(1).
+					Return([]db.LoginFailure{{
+						Scope:       lockout.ScopeUsername,
+						Identifier:  user.Username,
+						FailedCount: 5,
+						LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			// the username isn't counted once the client IP is locked out
+			name:     "client ip locked out",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginFailure{}, sql.ErrNoRows)
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginFailure{{
+						Scope:       lockout.ScopeClientIP,
+						Identifier:  clientIP,
+						FailedCount: 20,
+						LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name:     "internal server error",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				countAttempts(store)
 				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
@@ -257,13 +440,14 @@ func TestLoginUserAPI(t *testing.T) {
 
 			url := fmt.Sprintf("/users/login")
 
-			body := fmt.Sprintf(`{"username": "%v", "password": "%v"}`, user.Username, password)
+			body := fmt.Sprintf(`{"username": "%v", "password": "%v"}`, user.Username, tc.password)
 			jsonBody := []byte(body)
 			bodyReader := bytes.NewReader(jsonBody)
 
 			request, err := http.NewRequest(http.MethodPost, url, bodyReader)
 			// check request
 			require.NoError(t, err)
+			request.RemoteAddr = clientIP + ":54321"
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
@@ -271,6 +455,201 @@ func TestLoginUserAPI(t *testing.T) {
 	}
 }
 
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser()
+	secretCode, err := utils.RandomSecret(32)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		emailID       int64
+		secretCode    string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "happy path verify email",
+			emailID:    1,
+			secretCode: secretCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(db.VerifyEmailTxParams{
+					EmailID:        1,
+					SecretCodeHash: utils.HashSecret(secretCode),
+				})).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+				validateResponseUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name:       "used or expired code",
+			emailID:    1,
+			secretCode: secretCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "invalid secret code",
+			emailID:    1,
+			secretCode: "short",
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "internal server error",
+			emailID:    1,
+			secretCode: secretCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			url := fmt.Sprintf("/users/verify_email?email_id=%d&secret_code=%s", tc.emailID, tc.secretCode)
+
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			// check request
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser()
+	user.IsEmailVerified = false
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path resend verify email",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+						require.Equal(t, user.Email, arg.Email)
+						// only the hash of the mailed code is stored
+						require.Len(t, arg.SecretCodeHash, 64)
+						return db.VerifyEmail{ID: 1, Username: arg.Username, Email: arg.Email}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "email already verified",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verified, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "no authorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				// build stubs
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// check response
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/verify_email/resend", nil)
+			// check request
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.token)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func randomUser() (db.User, string) {
 	password := utils.RandomString(10)
 	hashedPassword, _ := utils.HashPassword(password)
@@ -279,6 +658,8 @@ func randomUser() (db.User, string) {
 		HashedPassword: hashedPassword,
 		FullName:       utils.RandomOwner(),
 		Email:          utils.RandomEmail(),
+		// most handlers require a verified email, tests for unverified users override it
+		IsEmailVerified: true,
 	}
 
 	return user, password
@@ -296,22 +677,31 @@ func validateResponseUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.FullName, rspUser.FullName)
 }
 
-func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
+
+	if err := utils.CheckPassword(e.password, arg.HashedPassword); err != nil {
+		return false
+	}
 	e.arg.HashedPassword = arg.HashedPassword
-	return reflect.DeepEqual(e.arg, arg)
+	if !reflect.DeepEqual(e.arg, arg.CreateUserParams) {
+		return false
+	}
+
+	// only the hash of the mailed code is stored
+	return len(arg.VerifyEmailSecretCodeHash) == 64
 }
 
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
-	return &eqCreateUserParamsMatcher{
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return &eqCreateUserTxParamsMatcher{
 		arg:      arg,
 		password: password,
 	}
 }
 
-func (e eqCreateUserParamsMatcher) String() string {
+func (e eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
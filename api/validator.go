@@ -2,7 +2,10 @@ package api
 
 import (
 	"github.com/go-playground/validator/v10"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	"github.com/micaelapucciariello/simplebank/oauth"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/webhook"
 )
 
 // build a validator to test different currencies in a more scalable way
@@ -12,3 +15,38 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validWebhookEvent validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if eventType, ok := fieldLevel.Field().Interface().(string); ok {
+		return webhook.IsSupportedEvent(eventType)
+	}
+	return false
+}
+
+var validWebhookURL validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if url, ok := fieldLevel.Field().Interface().(string); ok {
+		return webhook.ValidateURL(url) == nil
+	}
+	return false
+}
+
+var validRole validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if role, ok := fieldLevel.Field().Interface().(string); ok {
+		return utils.IsSupportedRole(role)
+	}
+	return false
+}
+
+var validRedirectURI validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if redirectURI, ok := fieldLevel.Field().Interface().(string); ok {
+		return oauth.ValidateRedirectURI(redirectURI) == nil
+	}
+	return false
+}
+
+var validApiKeyScope validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if scope, ok := fieldLevel.Field().Interface().(string); ok {
+		return apikey.IsSupportedScope(scope)
+	}
+	return false
+}
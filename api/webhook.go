@@ -0,0 +1,240 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/webhook"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"time"
+)
+
+type (
+	createWebhookSubscriptionReq struct {
+		URL        string   `json:"url" binding:"required,webhook_url"`
+		EventTypes []string `json:"event_types" binding:"required,min=1,dive,webhook_event"`
+		Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	}
+
+	webhookSubscriptionRsp struct {
+		ID         int64     `json:"id"`
+		URL        string    `json:"url"`
+		EventTypes []string  `json:"event_types"`
+		IsActive   bool      `json:"is_active"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	createWebhookSubscriptionRsp struct {
+		webhookSubscriptionRsp
+		Secret string `json:"secret"`
+	}
+
+	webhookSubscriptionURI struct {
+		ID int64 `uri:"id" binding:"required,min=1"`
+	}
+
+	webhookDeliveryURI struct {
+		ID         int64 `uri:"id" binding:"required,min=1"`
+		DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+	}
+
+	listWebhooksReq struct {
+		PageID   int32 `form:"page_id" binding:"required,min=1"`
+		PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	}
+)
+
+var errWebhookNotOwned = errors.New("webhook subscription doesn't belong to the authenticated user")
+
+func parseWebhookSubscription(subscription db.WebhookSubscription) webhookSubscriptionRsp {
+	return webhookSubscriptionRsp{
+		ID:         subscription.ID,
+		URL:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		IsActive:   subscription.IsActive,
+		CreatedAt:  subscription.CreatedAt.Time,
+	}
+}
+
+func (s *Server) createWebhookSubscription(ctx *gin.Context) {
+	var req createWebhookSubscriptionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		secret, err = webhook.NewSecret()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	subscription, err := s.store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:      authPayload.UserName,
+		Url:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusForbidden, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	rsp := createWebhookSubscriptionRsp{
+		webhookSubscriptionRsp: parseWebhookSubscription(subscription),
+		Secret:                 subscription.Secret,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (s *Server) listWebhookSubscriptions(ctx *gin.Context) {
+	var req listWebhooksReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	subscriptions, err := s.store.ListWebhookSubscriptions(ctx, db.ListWebhookSubscriptionsParams{
+		Owner:  authPayload.UserName,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	rsp := make([]webhookSubscriptionRsp, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		rsp = append(rsp, parseWebhookSubscription(subscription))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (s *Server) deleteWebhookSubscription(ctx *gin.Context) {
+	var req webhookSubscriptionURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if _, ok := s.ownedWebhookSubscription(ctx, req.ID); !ok {
+		return
+	}
+
+	err := s.store.DeleteWebhookSubscription(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"webhook": req.ID})
+}
+
+func (s *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri webhookSubscriptionURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req listWebhooksReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if _, ok := s.ownedWebhookSubscription(ctx, uri.ID); !ok {
+		return
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: uri.ID,
+		Limit:          req.PageSize,
+		Offset:         (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// replayWebhookDelivery puts a delivery back in the queue, including dead ones
+func (s *Server) replayWebhookDelivery(ctx *gin.Context) {
+	var req webhookDeliveryURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if _, ok := s.ownedWebhookSubscription(ctx, req.ID); !ok {
+		return
+	}
+
+	delivery, err := s.store.GetWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if delivery.SubscriptionID != req.ID {
+		ctx.JSON(http.StatusNotFound, errResponse(sql.ErrNoRows))
+		return
+	}
+
+	delivery, err = s.store.ReplayWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// ownedWebhookSubscription fetches a subscription and checks it belongs to the caller.
+// It writes the error response itself and returns false if the request must stop
+func (s *Server) ownedWebhookSubscription(ctx *gin.Context, id int64) (db.WebhookSubscription, bool) {
+	subscription, err := s.store.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errResponse(err))
+			return subscription, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return subscription, false
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+	if subscription.Owner != authPayload.UserName {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errWebhookNotOwned))
+		return subscription, false
+	}
+
+	return subscription, true
+}
+
+// publishEvent queues webhook deliveries without failing the request that triggered the event
+func (s *Server) publishEvent(ctx *gin.Context, owner string, eventType string, data interface{}) {
+	if err := s.webhooks.Publish(ctx, owner, eventType, data); err != nil {
+		log.Error().Err(err).Str("event", eventType).Str("owner", owner).Msg("cannot publish webhook event")
+	}
+}
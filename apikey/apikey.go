@@ -0,0 +1,147 @@
+// Package apikey issues and authenticates the scoped API keys services use instead of a user's access token
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeAccountsRead   = "accounts:read"
+	ScopeAccountsWrite  = "accounts:write"
+	ScopeTransfersWrite = "transfers:write"
+	ScopeWebhooksRead   = "webhooks:read"
+	ScopeWebhooksWrite  = "webhooks:write"
+)
+
+// keyPrefix makes keys easy to spot in logs and by secret scanners
+const keyPrefix = "sbk"
+
+var (
+	ErrInvalidKey = errors.New("api key is invalid")
+	ErrExpiredKey = errors.New("api key has expired")
+	ErrRevokedKey = errors.New("api key was revoked")
+)
+
+// IsSupportedScope returns true if the scope is supported
+func IsSupportedScope(scope string) bool {
+	switch scope {
+	case ScopeAccountsRead, ScopeAccountsWrite, ScopeTransfersWrite, ScopeWebhooksRead, ScopeWebhooksWrite:
+		return true
+	}
+	return false
+}
+
+// Generate returns a new key with the form sbk_<prefix>_<secret>. Only the prefix, which identifies the key,
+// and the hash of the whole key are stored
+func Generate() (key string, prefix string, hashedKey string, err error) {
+	prefix, err = utils.RandomSecret(4)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret, err := utils.RandomSecret(24)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = fmt.Sprintf("%s_%s_%s", keyPrefix, prefix, secret)
+	return key, prefix, utils.HashSecret(key), nil
+}
+
+// Prefix returns the public part of the key used to look it up
+func Prefix(key string) (string, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", ErrInvalidKey
+	}
+	return parts[1], nil
+}
+
+// Authenticator checks the keys sent by the clients against the stored ones
+type Authenticator struct {
+	store db.Store
+}
+
+func NewAuthenticator(store db.Store) *Authenticator {
+	return &Authenticator{store: store}
+}
+
+// Authenticate returns the stored key if it's valid and records it was used
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (db.ApiKey, error) {
+	prefix, err := Prefix(key)
+	if err != nil {
+		return db.ApiKey{}, err
+	}
+
+	apiKey, err := a.store.GetApiKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.ApiKey{}, ErrInvalidKey
+		}
+		return db.ApiKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.HashedKey), []byte(utils.HashSecret(key))) != 1 {
+		return db.ApiKey{}, ErrInvalidKey
+	}
+
+	if apiKey.RevokedAt.Valid {
+		return db.ApiKey{}, ErrRevokedKey
+	}
+
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return db.ApiKey{}, ErrExpiredKey
+	}
+
+	if err = a.store.TouchApiKey(ctx, apiKey.ID); err != nil {
+		return db.ApiKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+// Check returns an error when the key of the payload was revoked or expired since it was authenticated,
+// long lived requests call it to stop serving a key that is no longer valid
+func (a *Authenticator) Check(ctx context.Context, payload *token.Payload) error {
+	apiKey, err := a.store.GetApiKey(ctx, payload.APIKeyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidKey
+		}
+		return err
+	}
+
+	if apiKey.RevokedAt.Valid {
+		return ErrRevokedKey
+	}
+
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return ErrExpiredKey
+	}
+
+	return nil
+}
+
+// NewPayload returns the payload the handlers see for requests authenticated with the key. It has no role,
+// so the key only reaches the resources of its owner and only with its scopes
+func NewPayload(apiKey db.ApiKey) *token.Payload {
+	payload := &token.Payload{
+		UserName: apiKey.Username,
+		IssuedAt: apiKey.CreatedAt,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}
+	if apiKey.ExpiresAt.Valid {
+		payload.ExpiredAt = apiKey.ExpiresAt.Time
+	}
+	return payload
+}
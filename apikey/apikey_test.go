@@ -0,0 +1,186 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func randomApiKey(t *testing.T) (string, db.ApiKey) {
+	key, prefix, hashedKey, err := Generate()
+	require.NoError(t, err)
+
+	return key, db.ApiKey{
+		ID:        utils.RandomInt(1, 1000),
+		Username:  utils.RandomOwner(),
+		Name:      utils.RandomString(6),
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    []string{ScopeAccountsRead},
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hashedKey, err := Generate()
+	require.NoError(t, err)
+	require.NotContains(t, hashedKey, key)
+
+	parsed, err := Prefix(key)
+	require.NoError(t, err)
+	require.Equal(t, prefix, parsed)
+
+	_, err = Prefix("sbk_" + prefix)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, apiKey := randomApiKey(t)
+
+	testCases := []struct {
+		name       string
+		key        string
+		buildStubs func(store *mockdb.MockStore)
+		err        error
+	}{
+		{
+			name: "valid key",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			},
+		},
+		{
+			name: "wrong secret",
+			key:  key[:len(key)-1] + "x",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: ErrInvalidKey,
+		},
+		{
+			name: "unknown prefix",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			err: ErrInvalidKey,
+		},
+		{
+			name: "revoked key",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := apiKey
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: ErrRevokedKey,
+		},
+		{
+			name: "expired key",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := apiKey
+				expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+				store.EXPECT().TouchApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: ErrExpiredKey,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			authenticated, err := NewAuthenticator(store).Authenticate(context.Background(), tc.key)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, apiKey.ID, authenticated.ID)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	_, apiKey := randomApiKey(t)
+	payload := NewPayload(apiKey)
+
+	testCases := []struct {
+		name   string
+		stored func() (db.ApiKey, error)
+		err    error
+	}{
+		{
+			name:   "valid key",
+			stored: func() (db.ApiKey, error) { return apiKey, nil },
+		},
+		{
+			name:   "deleted key",
+			stored: func() (db.ApiKey, error) { return db.ApiKey{}, sql.ErrNoRows },
+			err:    ErrInvalidKey,
+		},
+		{
+			name: "revoked since authenticated",
+			stored: func() (db.ApiKey, error) {
+				revoked := apiKey
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				return revoked, nil
+			},
+			err: ErrRevokedKey,
+		},
+		{
+			name: "expired since authenticated",
+			stored: func() (db.ApiKey, error) {
+				expired := apiKey
+				expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				return expired, nil
+			},
+			err: ErrExpiredKey,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetApiKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(tc.stored())
+
+			err := NewAuthenticator(store).Check(context.Background(), payload)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	_, apiKey := randomApiKey(t)
+
+	payload := NewPayload(apiKey)
+	require.Equal(t, apiKey.Username, payload.UserName)
+	require.Empty(t, payload.Role)
+	require.True(t, payload.HasScope(ScopeAccountsRead))
+	require.False(t, payload.HasScope(ScopeTransfersWrite))
+}
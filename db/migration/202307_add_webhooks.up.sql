@@ -0,0 +1,35 @@
+CREATE TABLE "webhook_subscriptions"
+(
+    "id"          BIGSERIAL PRIMARY KEY,
+    "owner"       varchar   NOT NULL,
+    "url"         varchar   NOT NULL,
+    "event_types" varchar[] NOT NULL,
+    "secret"      varchar   NOT NULL,
+    "is_active"   boolean   NOT NULL DEFAULT TRUE,
+    "created_at"  timestamp          DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries"
+(
+    "id"                 BIGSERIAL PRIMARY KEY,
+    "subscription_id"    bigint    NOT NULL,
+    "event_type"         varchar   NOT NULL,
+    "payload"            jsonb     NOT NULL,
+    "status"             varchar   NOT NULL DEFAULT 'pending',
+    "attempts"           integer   NOT NULL DEFAULT 0,
+    "next_attempt_at"    timestamp NOT NULL DEFAULT (now()),
+    "last_response_code" integer,
+    "last_error"         varchar   NOT NULL DEFAULT '',
+    "delivered_at"       timestamp,
+    "created_at"         timestamp          DEFAULT (now())
+);
+
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "next_attempt_at");
+
+ALTER TABLE "webhook_subscriptions" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE;
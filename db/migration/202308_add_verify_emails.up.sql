@@ -0,0 +1,16 @@
+CREATE TABLE "verify_emails"
+(
+    "id"               BIGSERIAL PRIMARY KEY,
+    "username"         varchar   NOT NULL,
+    "email"            varchar   NOT NULL,
+    "secret_code_hash" varchar   NOT NULL,
+    "is_used"          boolean   NOT NULL DEFAULT FALSE,
+    "created_at"       timestamp NOT NULL DEFAULT (now()),
+    "expired_at"       timestamp NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+-- the users that signed up before the verification existed keep their access, new users start unverified
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT TRUE;
+ALTER TABLE "users" ALTER COLUMN "is_email_verified" SET DEFAULT FALSE;
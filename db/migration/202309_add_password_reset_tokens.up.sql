@@ -0,0 +1,13 @@
+CREATE TABLE "password_reset_tokens"
+(
+    "id"         BIGSERIAL PRIMARY KEY,
+    "username"   varchar        NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "is_used"    boolean        NOT NULL DEFAULT FALSE,
+    "created_at" timestamp      NOT NULL DEFAULT (now()),
+    "expired_at" timestamp      NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+CREATE INDEX ON "password_reset_tokens" ("username");
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,39 @@
+CREATE TABLE "totp_credentials"
+(
+    "username"       varchar PRIMARY KEY,
+    "secret"         varchar   NOT NULL,
+    "is_confirmed"   boolean   NOT NULL DEFAULT FALSE,
+    "created_at"     timestamp NOT NULL DEFAULT (now()),
+    "confirmed_at"   timestamp,
+    "last_used_step" bigint    NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "mfa_recovery_codes"
+(
+    "id"         BIGSERIAL PRIMARY KEY,
+    "username"   varchar   NOT NULL,
+    "code_hash"  varchar   NOT NULL,
+    "used_at"    timestamp,
+    "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_challenges"
+(
+    "id"         BIGSERIAL PRIMARY KEY,
+    "username"   varchar        NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "attempts"   integer        NOT NULL DEFAULT 0,
+    "is_used"    boolean        NOT NULL DEFAULT FALSE,
+    "created_at" timestamp      NOT NULL DEFAULT (now()),
+    "expired_at" timestamp      NOT NULL
+);
+
+CREATE INDEX ON "mfa_recovery_codes" ("username");
+
+CREATE INDEX ON "mfa_challenges" ("username");
+
+ALTER TABLE "totp_credentials" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
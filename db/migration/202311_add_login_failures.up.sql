@@ -0,0 +1,9 @@
+CREATE TABLE "login_failures"
+(
+    "scope"          varchar   NOT NULL,
+    "identifier"     varchar   NOT NULL,
+    "failed_count"   integer   NOT NULL DEFAULT 0,
+    "last_failed_at" timestamp NOT NULL DEFAULT (now()),
+    "locked_until"   timestamp,
+    PRIMARY KEY ("scope", "identifier")
+);
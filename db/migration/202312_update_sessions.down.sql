@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS sessions_username_idx;
+
+ALTER TABLE "sessions" ADD CONSTRAINT "sessions_client_ip_key" UNIQUE ("client_ip");
@@ -0,0 +1,3 @@
+ALTER TABLE "sessions" DROP CONSTRAINT IF EXISTS "sessions_client_ip_key";
+
+CREATE INDEX ON "sessions" ("username");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS sessions_family_id_idx;
+
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "consumed_at";
+
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "family_id";
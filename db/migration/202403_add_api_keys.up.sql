@@ -0,0 +1,17 @@
+CREATE TABLE "api_keys"
+(
+    "id"           BIGSERIAL PRIMARY KEY,
+    "username"     varchar        NOT NULL,
+    "name"         varchar        NOT NULL,
+    "prefix"       varchar UNIQUE NOT NULL,
+    "hashed_key"   varchar        NOT NULL,
+    "scopes"       varchar[]      NOT NULL,
+    "expires_at"   timestamp,
+    "last_used_at" timestamp,
+    "revoked_at"   timestamp,
+    "created_at"   timestamp      NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "api_keys" ("username");
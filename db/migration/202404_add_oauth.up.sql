@@ -0,0 +1,47 @@
+CREATE TABLE "oauth_clients"
+(
+    "id"            BIGSERIAL PRIMARY KEY,
+    "client_id"     varchar UNIQUE NOT NULL,
+    "hashed_secret" varchar        NOT NULL DEFAULT '',
+    "name"          varchar        NOT NULL,
+    "owner"         varchar        NOT NULL,
+    "redirect_uris" varchar[]      NOT NULL,
+    "scopes"        varchar[]      NOT NULL,
+    "created_at"    timestamp      NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oauth_consents"
+(
+    "id"         BIGSERIAL PRIMARY KEY,
+    "username"   varchar   NOT NULL,
+    "client_id"  varchar   NOT NULL,
+    "scopes"     varchar[] NOT NULL,
+    "revoked_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oauth_authorization_codes"
+(
+    "hashed_code"    varchar PRIMARY KEY,
+    "client_id"      varchar   NOT NULL,
+    "username"       varchar   NOT NULL,
+    "redirect_uri"   varchar   NOT NULL,
+    "scopes"         varchar[] NOT NULL,
+    "code_challenge" varchar   NOT NULL,
+    "expires_at"     timestamp NOT NULL,
+    "used_at"        timestamp,
+    "created_at"     timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "oauth_clients" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "oauth_consents" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "oauth_consents" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("client_id");
+
+ALTER TABLE "oauth_authorization_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "oauth_authorization_codes" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("client_id");
+
+-- a user has one consent per client, approving the client again replaces it
+CREATE UNIQUE INDEX ON "oauth_consents" ("username", "client_id");
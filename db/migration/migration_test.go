@@ -0,0 +1,12 @@
+package migration
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := LatestVersion()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, version, uint(202404))
+}
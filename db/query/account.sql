@@ -36,7 +36,20 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: DebitAccountBalance :one
+UPDATE accounts
+SET balance = balance - sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+  AND balance >= sqlc.arg(amount)
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE
 FROM accounts
 WHERE id = $1;
+
+-- name: SetAccountFrozen :one
+UPDATE accounts
+SET is_frozen = $2
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,17 @@
+-- name: NotifyAccountActivity :exec
+SELECT pg_notify('account_activity', sqlc.arg(payload)::text);
+
+-- name: ListAccountActivity :many
+SELECT e.id,
+       e.account_id,
+       e.amount,
+       e.created_at,
+       (a.balance - (SELECT COALESCE(SUM(later.amount), 0)
+                     FROM entries later
+                     WHERE later.account_id = e.account_id
+                       AND later.id > e.id))::bigint AS balance
+FROM entries e
+         JOIN accounts a ON a.id = e.account_id
+WHERE a.owner = $1
+  AND e.id > $2
+ORDER BY e.id LIMIT $3;
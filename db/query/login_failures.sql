@@ -0,0 +1,50 @@
+-- name: ListLoginFailures :many
+SELECT *
+FROM login_failures
+WHERE (scope = 'username' AND identifier = sqlc.arg(username))
+   OR (scope = 'client_ip' AND identifier = sqlc.arg(client_ip));
+
+-- name: RecordLoginAttempt :one
+INSERT INTO login_failures (scope,
+                            identifier,
+                            failed_count,
+                            last_failed_at,
+                            locked_until)
+VALUES (sqlc.arg(scope), sqlc.arg(identifier), 1, now(),
+        CASE WHEN sqlc.arg(max_failures)::integer = 1 THEN sqlc.arg(lock_until)::timestamp END) ON CONFLICT (scope, identifier) DO
+UPDATE
+SET failed_count   = CASE
+                         WHEN login_failures.last_failed_at < sqlc.arg(window_start) THEN 1
+                         ELSE login_failures.failed_count + 1
+    END,
+    last_failed_at = now(),
+    locked_until   = CASE
+                         WHEN sqlc.arg(max_failures) > 0 AND CASE
+                                                                 WHEN login_failures.last_failed_at < sqlc.arg(window_start) THEN 1
+                                                                 ELSE login_failures.failed_count + 1
+                             END >= sqlc.arg(max_failures) THEN sqlc.arg(lock_until)
+                         ELSE login_failures.locked_until
+    END
+WHERE login_failures.locked_until IS NULL
+   OR login_failures.locked_until <= now() RETURNING *;
+
+-- name: DelayLogin :exec
+UPDATE login_failures
+SET locked_until = GREATEST(locked_until, now() + make_interval(secs => LEAST(
+        sqlc.arg(base_delay_seconds)::float * power(2, failed_count - 1), sqlc.arg(max_delay_seconds)::float)))
+WHERE scope = sqlc.arg(scope)
+  AND identifier = sqlc.arg(identifier);
+
+-- name: ForgetLoginAttempt :exec
+UPDATE login_failures
+SET failed_count = failed_count - 1,
+    locked_until = CASE WHEN failed_count - 1 < sqlc.arg(max_failures)::integer THEN NULL ELSE locked_until END
+WHERE scope = sqlc.arg(scope)
+  AND identifier = sqlc.arg(identifier)
+  AND failed_count > 0;
+
+-- name: DeleteLoginFailure :exec
+DELETE
+FROM login_failures
+WHERE scope = $1
+  AND identifier = $2;
@@ -0,0 +1,66 @@
+-- name: UpsertTotpCredential :one
+INSERT INTO totp_credentials (username,
+                              secret)
+VALUES ($1, $2) ON CONFLICT (username) DO
+UPDATE
+SET secret       = EXCLUDED.secret,
+    is_confirmed = FALSE,
+    confirmed_at = NULL,
+    created_at   = now()
+WHERE totp_credentials.is_confirmed = FALSE RETURNING *;
+
+-- name: GetTotpCredential :one
+SELECT *
+FROM totp_credentials
+WHERE username = $1 LIMIT 1;
+
+-- name: ConfirmTotpCredential :one
+UPDATE totp_credentials
+SET is_confirmed = TRUE,
+    confirmed_at = now()
+WHERE username = $1 RETURNING *;
+
+-- name: UseTotpStep :one
+UPDATE totp_credentials
+SET last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND last_used_step < sqlc.arg(step) RETURNING *;
+
+-- name: CreateMfaRecoveryCode :one
+INSERT INTO mfa_recovery_codes (username,
+                                code_hash)
+VALUES ($1, $2) RETURNING *;
+
+-- name: DeleteMfaRecoveryCodes :exec
+DELETE
+FROM mfa_recovery_codes
+WHERE username = $1;
+
+-- name: UseMfaRecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE username = $1
+  AND code_hash = $2
+  AND used_at IS NULL RETURNING *;
+
+-- name: CreateMfaChallenge :one
+INSERT INTO mfa_challenges (username,
+                            token_hash,
+                            expired_at)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetMfaChallenge :one
+SELECT *
+FROM mfa_challenges
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: IncrementMfaChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1 RETURNING *;
+
+-- name: UseMfaChallenge :one
+UPDATE mfa_challenges
+SET is_used = TRUE
+WHERE id = $1
+  AND is_used = FALSE RETURNING *;
@@ -0,0 +1,59 @@
+-- name: CreateOauthClient :one
+INSERT INTO oauth_clients (client_id,
+                           hashed_secret,
+                           name,
+                           owner,
+                           redirect_uris,
+                           scopes)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetOauthClient :one
+SELECT *
+FROM oauth_clients
+WHERE client_id = $1 LIMIT 1;
+
+-- name: UpsertOauthConsent :one
+INSERT INTO oauth_consents (username,
+                            client_id,
+                            scopes)
+VALUES ($1, $2, $3)
+ON CONFLICT (username, client_id) DO UPDATE
+    SET scopes     = EXCLUDED.scopes,
+        revoked_at = NULL,
+        created_at = now() RETURNING *;
+
+-- name: GetOauthConsent :one
+SELECT *
+FROM oauth_consents
+WHERE username = $1
+  AND client_id = $2 LIMIT 1;
+
+-- name: ListOauthConsents :many
+SELECT *
+FROM oauth_consents
+WHERE username = $1
+  AND revoked_at IS NULL
+ORDER BY id;
+
+-- name: RevokeOauthConsent :one
+UPDATE oauth_consents
+SET revoked_at = now()
+WHERE username = $1
+  AND client_id = $2
+  AND revoked_at IS NULL RETURNING *;
+
+-- name: CreateOauthAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (hashed_code,
+                                       client_id,
+                                       username,
+                                       redirect_uri,
+                                       scopes,
+                                       code_challenge,
+                                       expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: UseOauthAuthorizationCode :one
+UPDATE oauth_authorization_codes
+SET used_at = now()
+WHERE hashed_code = $1
+  AND used_at IS NULL RETURNING *;
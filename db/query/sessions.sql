@@ -6,10 +6,47 @@ refresh_token,
 user_agent,
 client_ip,
 is_blocked,
-expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+expires_at,
+family_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
 
 -- name: GetSession :one
 SELECT *
 FROM sessions
 WHERE id = $1 LIMIT 1;
+
+-- name: GetSessionForUpdate :one
+SELECT *
+FROM sessions
+WHERE id = $1 LIMIT 1 FOR NO KEY
+UPDATE;
+
+-- name: ConsumeSession :one
+UPDATE sessions
+SET consumed_at = now()
+WHERE id = $1 RETURNING *;
+
+-- name: BlockSessionFamily :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE family_id = $1;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1;
+
+-- name: ListActiveSessions :many
+SELECT *
+FROM sessions
+WHERE username = $1
+  AND is_blocked = FALSE
+  AND consumed_at IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE id = $1
+  AND username = $2 RETURNING *;
@@ -24,10 +24,32 @@ OFFSET $2;
 
 -- name: UpdateUser :one
 UPDATE users
-SET hashed_password = $2, password_changed_at = $3, email =$4, full_name = $5
+SET hashed_password = $2, password_changed_at = $3, email =$4, full_name = $5,
+    is_email_verified = is_email_verified AND email = $4
 WHERE username = $1 RETURNING *;
 
 -- name: DeleteUser :exec
 DELETE
 FROM users
 WHERE username = $1;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = TRUE
+WHERE username = $1
+  AND email = $2 RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at
+FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1 RETURNING *;
@@ -0,0 +1,77 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (owner,
+                                   url,
+                                   event_types,
+                                   secret)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT *
+FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id LIMIT $2
+OFFSET $3;
+
+-- name: ListWebhookSubscriptionsByEvent :many
+SELECT *
+FROM webhook_subscriptions
+WHERE owner = sqlc.arg(owner)
+  AND is_active = TRUE
+  AND sqlc.arg(event_type)::varchar = ANY (event_types)
+ORDER BY id;
+
+-- name: DeleteWebhookSubscription :exec
+DELETE
+FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (subscription_id,
+                                event_type,
+                                payload)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id DESC LIMIT $2
+OFFSET $3;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (SELECT id
+             FROM webhook_deliveries
+             WHERE status = 'pending'
+               AND next_attempt_at <= now()
+             ORDER BY next_attempt_at LIMIT sqlc.arg(batch_size)
+             FOR UPDATE SKIP LOCKED) RETURNING *;
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status             = $2,
+    attempts           = $3,
+    next_attempt_at    = $4,
+    last_response_code = $5,
+    last_error         = $6,
+    delivered_at       = $7
+WHERE id = $1 RETURNING *;
+
+-- name: ReplayWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status          = 'pending',
+    attempts        = 0,
+    next_attempt_at = now(),
+    last_error      = ''
+WHERE id = $1 RETURNING *;
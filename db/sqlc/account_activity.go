@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AccountActivityChannel is the Postgres channel TransferTx notifies the new entries on
+const AccountActivityChannel = "account_activity"
+
+// AccountActivity is the payload of the notifications on AccountActivityChannel
+type AccountActivity struct {
+	EntryID   int64  `json:"entry_id"`
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Amount    int64  `json:"amount"`
+	// Balance of the account right after the entry
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func publishAccountActivity(ctx context.Context, q *Queries, entry Entry, account Account) error {
+	payload, err := json.Marshal(AccountActivity{
+		EntryID:   entry.ID,
+		AccountID: account.ID,
+		Owner:     account.Owner,
+		Amount:    entry.Amount,
+		Balance:   account.Balance,
+		CreatedAt: entry.CreatedAt.Time,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.NotifyAccountActivity(ctx, string(payload))
+}
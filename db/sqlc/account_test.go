@@ -9,10 +9,14 @@ import (
 )
 
 func CreateRandomAccount(t *testing.T) Account {
+	return createAccountWithBalance(t, utils.RandomBalance())
+}
+
+func createAccountWithBalance(t *testing.T, balance int64) Account {
 	user := CreateRandomUser(t)
 	args := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  utils.RandomBalance(),
+		Balance:  balance,
 		Currency: utils.RandomCurrency(),
 	}
 
@@ -24,6 +28,7 @@ func CreateRandomAccount(t *testing.T) Account {
 	require.Equal(t, args.Owner, account.Owner)
 	require.Equal(t, args.Balance, account.Balance)
 	require.Equal(t, args.Currency, account.Currency)
+	require.False(t, account.IsFrozen)
 
 	require.NotZero(t, account.CreatedAt)
 	require.NotZero(t, account.ID)
@@ -72,6 +77,18 @@ func TestUpdateAccount(t *testing.T) {
 	require.WithinDuration(t, a.CreatedAt.Time, account.CreatedAt.Time, time.Second)
 }
 
+func TestSetAccountFrozen(t *testing.T) {
+	a := CreateRandomAccount(t)
+
+	account, err := testQueries.SetAccountFrozen(context.Background(), SetAccountFrozenParams{
+		ID:       a.ID,
+		IsFrozen: true,
+	})
+	require.NoError(t, err)
+	require.True(t, account.IsFrozen)
+	require.Equal(t, a.Balance, account.Balance)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	a := CreateRandomAccount(t)
 	err := testQueries.DeleteAccount(context.Background(), a.ID)
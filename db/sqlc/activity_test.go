@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestListAccountActivity(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := CreateRandomAccount(t)
+	account2 := CreateRandomAccount(t)
+
+	var results []TransferTxResult
+	for i := 0; i < 3; i++ {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	// resuming after the first transfer replays the next ones with the balance right after each entry
+	rows, err := testQueries.ListAccountActivity(context.Background(), ListAccountActivityParams{
+		Owner: account1.Owner,
+		ID:    results[0].FromEntry.ID,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for i, row := range rows {
+		result := results[i+1]
+		require.Equal(t, result.FromEntry.ID, row.ID)
+		require.Equal(t, account1.ID, row.AccountID)
+		require.Equal(t, int64(-10), row.Amount)
+		require.Equal(t, result.FromAccountID.Balance, row.Balance)
+	}
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomApiKey(t *testing.T) ApiKey {
+	user := CreateRandomUser(t)
+	args := CreateApiKeyParams{
+		Username:  user.Username,
+		Name:      utils.RandomString(6),
+		Prefix:    utils.RandomString(8),
+		HashedKey: utils.RandomString(64),
+		Scopes:    []string{"accounts:read", "transfers:write"},
+	}
+
+	apiKey, err := testQueries.CreateApiKey(context.Background(), args)
+	require.NoError(t, err)
+	require.NotEmpty(t, apiKey)
+
+	require.Equal(t, args.Username, apiKey.Username)
+	require.Equal(t, args.Name, apiKey.Name)
+	require.Equal(t, args.Prefix, apiKey.Prefix)
+	require.Equal(t, args.HashedKey, apiKey.HashedKey)
+	require.Equal(t, args.Scopes, apiKey.Scopes)
+	require.False(t, apiKey.ExpiresAt.Valid)
+	require.False(t, apiKey.LastUsedAt.Valid)
+	require.False(t, apiKey.RevokedAt.Valid)
+	require.NotZero(t, apiKey.CreatedAt)
+
+	return apiKey
+}
+
+func TestCreateApiKey(t *testing.T) {
+	createRandomApiKey(t)
+}
+
+func TestGetApiKeyByPrefix(t *testing.T) {
+	apiKey1 := createRandomApiKey(t)
+
+	apiKey2, err := testQueries.GetApiKeyByPrefix(context.Background(), apiKey1.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, apiKey1.ID, apiKey2.ID)
+	require.Equal(t, apiKey1.HashedKey, apiKey2.HashedKey)
+	require.Equal(t, apiKey1.Scopes, apiKey2.Scopes)
+}
+
+func TestListApiKeys(t *testing.T) {
+	apiKey := createRandomApiKey(t)
+
+	apiKeys, err := testQueries.ListApiKeys(context.Background(), apiKey.Username)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 1)
+	require.Equal(t, apiKey.ID, apiKeys[0].ID)
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	apiKey := createRandomApiKey(t)
+
+	revoked, err := testQueries.RevokeApiKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+	require.WithinDuration(t, time.Now(), revoked.RevokedAt.Time, time.Second)
+
+	// revoking again keeps the first revocation time
+	again, err := testQueries.RevokeApiKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.Equal(t, revoked.RevokedAt.Time, again.RevokedAt.Time)
+}
+
+func TestTouchApiKey(t *testing.T) {
+	apiKey := createRandomApiKey(t)
+
+	err := testQueries.TouchApiKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+
+	touched, err := testQueries.GetApiKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+}
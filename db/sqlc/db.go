@@ -24,12 +24,48 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.blockSessionStmt, err = db.PrepareContext(ctx, blockSession); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockSession: %w", err)
+	}
+	if q.blockSessionFamilyStmt, err = db.PrepareContext(ctx, blockSessionFamily); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockSessionFamily: %w", err)
+	}
+	if q.blockUserSessionsStmt, err = db.PrepareContext(ctx, blockUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockUserSessions: %w", err)
+	}
+	if q.claimDueWebhookDeliveriesStmt, err = db.PrepareContext(ctx, claimDueWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDueWebhookDeliveries: %w", err)
+	}
+	if q.confirmTotpCredentialStmt, err = db.PrepareContext(ctx, confirmTotpCredential); err != nil {
+		return nil, fmt.Errorf("error preparing query ConfirmTotpCredential: %w", err)
+	}
+	if q.consumeSessionStmt, err = db.PrepareContext(ctx, consumeSession); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumeSession: %w", err)
+	}
 	if q.createAccountStmt, err = db.PrepareContext(ctx, createAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateAccount: %w", err)
 	}
+	if q.createApiKeyStmt, err = db.PrepareContext(ctx, createApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateApiKey: %w", err)
+	}
 	if q.createEntryStmt, err = db.PrepareContext(ctx, createEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateEntry: %w", err)
 	}
+	if q.createMfaChallengeStmt, err = db.PrepareContext(ctx, createMfaChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMfaChallenge: %w", err)
+	}
+	if q.createMfaRecoveryCodeStmt, err = db.PrepareContext(ctx, createMfaRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMfaRecoveryCode: %w", err)
+	}
+	if q.createOauthAuthorizationCodeStmt, err = db.PrepareContext(ctx, createOauthAuthorizationCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOauthAuthorizationCode: %w", err)
+	}
+	if q.createOauthClientStmt, err = db.PrepareContext(ctx, createOauthClient); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOauthClient: %w", err)
+	}
+	if q.createPasswordResetTokenStmt, err = db.PrepareContext(ctx, createPasswordResetToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePasswordResetToken: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
@@ -39,51 +75,162 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createVerifyEmailStmt, err = db.PrepareContext(ctx, createVerifyEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVerifyEmail: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.createWebhookSubscriptionStmt, err = db.PrepareContext(ctx, createWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookSubscription: %w", err)
+	}
+	if q.debitAccountBalanceStmt, err = db.PrepareContext(ctx, debitAccountBalance); err != nil {
+		return nil, fmt.Errorf("error preparing query DebitAccountBalance: %w", err)
+	}
+	if q.delayLoginStmt, err = db.PrepareContext(ctx, delayLogin); err != nil {
+		return nil, fmt.Errorf("error preparing query DelayLogin: %w", err)
+	}
 	if q.deleteAccountStmt, err = db.PrepareContext(ctx, deleteAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAccount: %w", err)
 	}
 	if q.deleteEntryStmt, err = db.PrepareContext(ctx, deleteEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteEntry: %w", err)
 	}
+	if q.deleteLoginFailureStmt, err = db.PrepareContext(ctx, deleteLoginFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLoginFailure: %w", err)
+	}
+	if q.deleteMfaRecoveryCodesStmt, err = db.PrepareContext(ctx, deleteMfaRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMfaRecoveryCodes: %w", err)
+	}
 	if q.deleteTransferStmt, err = db.PrepareContext(ctx, deleteTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteTransfer: %w", err)
 	}
 	if q.deleteUserStmt, err = db.PrepareContext(ctx, deleteUser); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteUser: %w", err)
 	}
+	if q.deleteWebhookSubscriptionStmt, err = db.PrepareContext(ctx, deleteWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookSubscription: %w", err)
+	}
+	if q.forgetLoginAttemptStmt, err = db.PrepareContext(ctx, forgetLoginAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query ForgetLoginAttempt: %w", err)
+	}
 	if q.getAccountStmt, err = db.PrepareContext(ctx, getAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccount: %w", err)
 	}
 	if q.getAccountForUpdateStmt, err = db.PrepareContext(ctx, getAccountForUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccountForUpdate: %w", err)
 	}
+	if q.getApiKeyStmt, err = db.PrepareContext(ctx, getApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApiKey: %w", err)
+	}
+	if q.getApiKeyByPrefixStmt, err = db.PrepareContext(ctx, getApiKeyByPrefix); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApiKeyByPrefix: %w", err)
+	}
 	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
 	}
+	if q.getMfaChallengeStmt, err = db.PrepareContext(ctx, getMfaChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMfaChallenge: %w", err)
+	}
+	if q.getOauthClientStmt, err = db.PrepareContext(ctx, getOauthClient); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOauthClient: %w", err)
+	}
+	if q.getOauthConsentStmt, err = db.PrepareContext(ctx, getOauthConsent); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOauthConsent: %w", err)
+	}
 	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
 	}
+	if q.getSessionForUpdateStmt, err = db.PrepareContext(ctx, getSessionForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionForUpdate: %w", err)
+	}
+	if q.getTotpCredentialStmt, err = db.PrepareContext(ctx, getTotpCredential); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTotpCredential: %w", err)
+	}
 	if q.getTransferStmt, err = db.PrepareContext(ctx, getTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTransfer: %w", err)
 	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
+	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
+	}
 	if q.getUserForUpdateStmt, err = db.PrepareContext(ctx, getUserForUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserForUpdate: %w", err)
 	}
+	if q.getUserPasswordChangedAtStmt, err = db.PrepareContext(ctx, getUserPasswordChangedAt); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserPasswordChangedAt: %w", err)
+	}
+	if q.getWebhookDeliveryStmt, err = db.PrepareContext(ctx, getWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDelivery: %w", err)
+	}
+	if q.getWebhookSubscriptionStmt, err = db.PrepareContext(ctx, getWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookSubscription: %w", err)
+	}
+	if q.incrementMfaChallengeAttemptsStmt, err = db.PrepareContext(ctx, incrementMfaChallengeAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementMfaChallengeAttempts: %w", err)
+	}
+	if q.invalidatePasswordResetTokensStmt, err = db.PrepareContext(ctx, invalidatePasswordResetTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query InvalidatePasswordResetTokens: %w", err)
+	}
+	if q.listAccountActivityStmt, err = db.PrepareContext(ctx, listAccountActivity); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountActivity: %w", err)
+	}
 	if q.listAccountsStmt, err = db.PrepareContext(ctx, listAccounts); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAccounts: %w", err)
 	}
+	if q.listActiveSessionsStmt, err = db.PrepareContext(ctx, listActiveSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveSessions: %w", err)
+	}
+	if q.listApiKeysStmt, err = db.PrepareContext(ctx, listApiKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListApiKeys: %w", err)
+	}
 	if q.listEntriesStmt, err = db.PrepareContext(ctx, listEntries); err != nil {
 		return nil, fmt.Errorf("error preparing query ListEntries: %w", err)
 	}
+	if q.listLoginFailuresStmt, err = db.PrepareContext(ctx, listLoginFailures); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLoginFailures: %w", err)
+	}
+	if q.listOauthConsentsStmt, err = db.PrepareContext(ctx, listOauthConsents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOauthConsents: %w", err)
+	}
 	if q.listTransfersStmt, err = db.PrepareContext(ctx, listTransfers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTransfers: %w", err)
 	}
 	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhookSubscriptionsStmt, err = db.PrepareContext(ctx, listWebhookSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookSubscriptions: %w", err)
+	}
+	if q.listWebhookSubscriptionsByEventStmt, err = db.PrepareContext(ctx, listWebhookSubscriptionsByEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookSubscriptionsByEvent: %w", err)
+	}
+	if q.notifyAccountActivityStmt, err = db.PrepareContext(ctx, notifyAccountActivity); err != nil {
+		return nil, fmt.Errorf("error preparing query NotifyAccountActivity: %w", err)
+	}
+	if q.recordLoginAttemptStmt, err = db.PrepareContext(ctx, recordLoginAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordLoginAttempt: %w", err)
+	}
+	if q.replayWebhookDeliveryStmt, err = db.PrepareContext(ctx, replayWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query ReplayWebhookDelivery: %w", err)
+	}
+	if q.revokeApiKeyStmt, err = db.PrepareContext(ctx, revokeApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeApiKey: %w", err)
+	}
+	if q.revokeOauthConsentStmt, err = db.PrepareContext(ctx, revokeOauthConsent); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeOauthConsent: %w", err)
+	}
+	if q.setAccountFrozenStmt, err = db.PrepareContext(ctx, setAccountFrozen); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAccountFrozen: %w", err)
+	}
+	if q.touchApiKeyStmt, err = db.PrepareContext(ctx, touchApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchApiKey: %w", err)
+	}
 	if q.updateAccountStmt, err = db.PrepareContext(ctx, updateAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateAccount: %w", err)
 	}
@@ -93,21 +240,114 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateUserStmt, err = db.PrepareContext(ctx, updateUser); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUser: %w", err)
 	}
+	if q.updateUserRoleStmt, err = db.PrepareContext(ctx, updateUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserRole: %w", err)
+	}
+	if q.updateWebhookDeliveryAttemptStmt, err = db.PrepareContext(ctx, updateWebhookDeliveryAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhookDeliveryAttempt: %w", err)
+	}
+	if q.upsertOauthConsentStmt, err = db.PrepareContext(ctx, upsertOauthConsent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertOauthConsent: %w", err)
+	}
+	if q.upsertTotpCredentialStmt, err = db.PrepareContext(ctx, upsertTotpCredential); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertTotpCredential: %w", err)
+	}
+	if q.useMfaChallengeStmt, err = db.PrepareContext(ctx, useMfaChallenge); err != nil {
+		return nil, fmt.Errorf("error preparing query UseMfaChallenge: %w", err)
+	}
+	if q.useMfaRecoveryCodeStmt, err = db.PrepareContext(ctx, useMfaRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseMfaRecoveryCode: %w", err)
+	}
+	if q.useOauthAuthorizationCodeStmt, err = db.PrepareContext(ctx, useOauthAuthorizationCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseOauthAuthorizationCode: %w", err)
+	}
+	if q.usePasswordResetTokenStmt, err = db.PrepareContext(ctx, usePasswordResetToken); err != nil {
+		return nil, fmt.Errorf("error preparing query UsePasswordResetToken: %w", err)
+	}
+	if q.useTotpStepStmt, err = db.PrepareContext(ctx, useTotpStep); err != nil {
+		return nil, fmt.Errorf("error preparing query UseTotpStep: %w", err)
+	}
+	if q.useVerifyEmailStmt, err = db.PrepareContext(ctx, useVerifyEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query UseVerifyEmail: %w", err)
+	}
+	if q.verifyUserEmailStmt, err = db.PrepareContext(ctx, verifyUserEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query VerifyUserEmail: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.blockSessionStmt != nil {
+		if cerr := q.blockSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockSessionStmt: %w", cerr)
+		}
+	}
+	if q.blockSessionFamilyStmt != nil {
+		if cerr := q.blockSessionFamilyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockSessionFamilyStmt: %w", cerr)
+		}
+	}
+	if q.blockUserSessionsStmt != nil {
+		if cerr := q.blockUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.claimDueWebhookDeliveriesStmt != nil {
+		if cerr := q.claimDueWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDueWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.confirmTotpCredentialStmt != nil {
+		if cerr := q.confirmTotpCredentialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing confirmTotpCredentialStmt: %w", cerr)
+		}
+	}
+	if q.consumeSessionStmt != nil {
+		if cerr := q.consumeSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumeSessionStmt: %w", cerr)
+		}
+	}
 	if q.createAccountStmt != nil {
 		if cerr := q.createAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createAccountStmt: %w", cerr)
 		}
 	}
+	if q.createApiKeyStmt != nil {
+		if cerr := q.createApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createApiKeyStmt: %w", cerr)
+		}
+	}
 	if q.createEntryStmt != nil {
 		if cerr := q.createEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createEntryStmt: %w", cerr)
 		}
 	}
+	if q.createMfaChallengeStmt != nil {
+		if cerr := q.createMfaChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMfaChallengeStmt: %w", cerr)
+		}
+	}
+	if q.createMfaRecoveryCodeStmt != nil {
+		if cerr := q.createMfaRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMfaRecoveryCodeStmt: %w", cerr)
+		}
+	}
+	if q.createOauthAuthorizationCodeStmt != nil {
+		if cerr := q.createOauthAuthorizationCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOauthAuthorizationCodeStmt: %w", cerr)
+		}
+	}
+	if q.createOauthClientStmt != nil {
+		if cerr := q.createOauthClientStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOauthClientStmt: %w", cerr)
+		}
+	}
+	if q.createPasswordResetTokenStmt != nil {
+		if cerr := q.createPasswordResetTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPasswordResetTokenStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
@@ -123,6 +363,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createVerifyEmailStmt != nil {
+		if cerr := q.createVerifyEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVerifyEmailStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookSubscriptionStmt != nil {
+		if cerr := q.createWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.debitAccountBalanceStmt != nil {
+		if cerr := q.debitAccountBalanceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing debitAccountBalanceStmt: %w", cerr)
+		}
+	}
+	if q.delayLoginStmt != nil {
+		if cerr := q.delayLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing delayLoginStmt: %w", cerr)
+		}
+	}
 	if q.deleteAccountStmt != nil {
 		if cerr := q.deleteAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAccountStmt: %w", cerr)
@@ -133,6 +398,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteEntryStmt: %w", cerr)
 		}
 	}
+	if q.deleteLoginFailureStmt != nil {
+		if cerr := q.deleteLoginFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLoginFailureStmt: %w", cerr)
+		}
+	}
+	if q.deleteMfaRecoveryCodesStmt != nil {
+		if cerr := q.deleteMfaRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMfaRecoveryCodesStmt: %w", cerr)
+		}
+	}
 	if q.deleteTransferStmt != nil {
 		if cerr := q.deleteTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteTransferStmt: %w", cerr)
@@ -143,6 +418,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteUserStmt: %w", cerr)
 		}
 	}
+	if q.deleteWebhookSubscriptionStmt != nil {
+		if cerr := q.deleteWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.forgetLoginAttemptStmt != nil {
+		if cerr := q.forgetLoginAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing forgetLoginAttemptStmt: %w", cerr)
+		}
+	}
 	if q.getAccountStmt != nil {
 		if cerr := q.getAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAccountStmt: %w", cerr)
@@ -153,16 +438,51 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAccountForUpdateStmt: %w", cerr)
 		}
 	}
+	if q.getApiKeyStmt != nil {
+		if cerr := q.getApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.getApiKeyByPrefixStmt != nil {
+		if cerr := q.getApiKeyByPrefixStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getApiKeyByPrefixStmt: %w", cerr)
+		}
+	}
 	if q.getEntryStmt != nil {
 		if cerr := q.getEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
 		}
 	}
+	if q.getMfaChallengeStmt != nil {
+		if cerr := q.getMfaChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMfaChallengeStmt: %w", cerr)
+		}
+	}
+	if q.getOauthClientStmt != nil {
+		if cerr := q.getOauthClientStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOauthClientStmt: %w", cerr)
+		}
+	}
+	if q.getOauthConsentStmt != nil {
+		if cerr := q.getOauthConsentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOauthConsentStmt: %w", cerr)
+		}
+	}
 	if q.getSessionStmt != nil {
 		if cerr := q.getSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
 		}
 	}
+	if q.getSessionForUpdateStmt != nil {
+		if cerr := q.getSessionForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getTotpCredentialStmt != nil {
+		if cerr := q.getTotpCredentialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTotpCredentialStmt: %w", cerr)
+		}
+	}
 	if q.getTransferStmt != nil {
 		if cerr := q.getTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTransferStmt: %w", cerr)
@@ -173,21 +493,76 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
 		}
 	}
+	if q.getUserByEmailStmt != nil {
+		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
+		}
+	}
 	if q.getUserForUpdateStmt != nil {
 		if cerr := q.getUserForUpdateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserForUpdateStmt: %w", cerr)
 		}
 	}
+	if q.getUserPasswordChangedAtStmt != nil {
+		if cerr := q.getUserPasswordChangedAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserPasswordChangedAtStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveryStmt != nil {
+		if cerr := q.getWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookSubscriptionStmt != nil {
+		if cerr := q.getWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.incrementMfaChallengeAttemptsStmt != nil {
+		if cerr := q.incrementMfaChallengeAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementMfaChallengeAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.invalidatePasswordResetTokensStmt != nil {
+		if cerr := q.invalidatePasswordResetTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing invalidatePasswordResetTokensStmt: %w", cerr)
+		}
+	}
+	if q.listAccountActivityStmt != nil {
+		if cerr := q.listAccountActivityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountActivityStmt: %w", cerr)
+		}
+	}
 	if q.listAccountsStmt != nil {
 		if cerr := q.listAccountsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAccountsStmt: %w", cerr)
 		}
 	}
+	if q.listActiveSessionsStmt != nil {
+		if cerr := q.listActiveSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveSessionsStmt: %w", cerr)
+		}
+	}
+	if q.listApiKeysStmt != nil {
+		if cerr := q.listApiKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listApiKeysStmt: %w", cerr)
+		}
+	}
 	if q.listEntriesStmt != nil {
 		if cerr := q.listEntriesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listEntriesStmt: %w", cerr)
 		}
 	}
+	if q.listLoginFailuresStmt != nil {
+		if cerr := q.listLoginFailuresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLoginFailuresStmt: %w", cerr)
+		}
+	}
+	if q.listOauthConsentsStmt != nil {
+		if cerr := q.listOauthConsentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOauthConsentsStmt: %w", cerr)
+		}
+	}
 	if q.listTransfersStmt != nil {
 		if cerr := q.listTransfersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTransfersStmt: %w", cerr)
@@ -198,6 +573,56 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
 		}
 	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookSubscriptionsStmt != nil {
+		if cerr := q.listWebhookSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookSubscriptionsByEventStmt != nil {
+		if cerr := q.listWebhookSubscriptionsByEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookSubscriptionsByEventStmt: %w", cerr)
+		}
+	}
+	if q.notifyAccountActivityStmt != nil {
+		if cerr := q.notifyAccountActivityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing notifyAccountActivityStmt: %w", cerr)
+		}
+	}
+	if q.recordLoginAttemptStmt != nil {
+		if cerr := q.recordLoginAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordLoginAttemptStmt: %w", cerr)
+		}
+	}
+	if q.replayWebhookDeliveryStmt != nil {
+		if cerr := q.replayWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing replayWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.revokeApiKeyStmt != nil {
+		if cerr := q.revokeApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.revokeOauthConsentStmt != nil {
+		if cerr := q.revokeOauthConsentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeOauthConsentStmt: %w", cerr)
+		}
+	}
+	if q.setAccountFrozenStmt != nil {
+		if cerr := q.setAccountFrozenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAccountFrozenStmt: %w", cerr)
+		}
+	}
+	if q.touchApiKeyStmt != nil {
+		if cerr := q.touchApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchApiKeyStmt: %w", cerr)
+		}
+	}
 	if q.updateAccountStmt != nil {
 		if cerr := q.updateAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAccountStmt: %w", cerr)
@@ -213,6 +638,61 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateUserStmt: %w", cerr)
 		}
 	}
+	if q.updateUserRoleStmt != nil {
+		if cerr := q.updateUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.updateWebhookDeliveryAttemptStmt != nil {
+		if cerr := q.updateWebhookDeliveryAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookDeliveryAttemptStmt: %w", cerr)
+		}
+	}
+	if q.upsertOauthConsentStmt != nil {
+		if cerr := q.upsertOauthConsentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertOauthConsentStmt: %w", cerr)
+		}
+	}
+	if q.upsertTotpCredentialStmt != nil {
+		if cerr := q.upsertTotpCredentialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertTotpCredentialStmt: %w", cerr)
+		}
+	}
+	if q.useMfaChallengeStmt != nil {
+		if cerr := q.useMfaChallengeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useMfaChallengeStmt: %w", cerr)
+		}
+	}
+	if q.useMfaRecoveryCodeStmt != nil {
+		if cerr := q.useMfaRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useMfaRecoveryCodeStmt: %w", cerr)
+		}
+	}
+	if q.useOauthAuthorizationCodeStmt != nil {
+		if cerr := q.useOauthAuthorizationCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useOauthAuthorizationCodeStmt: %w", cerr)
+		}
+	}
+	if q.usePasswordResetTokenStmt != nil {
+		if cerr := q.usePasswordResetTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing usePasswordResetTokenStmt: %w", cerr)
+		}
+	}
+	if q.useTotpStepStmt != nil {
+		if cerr := q.useTotpStepStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useTotpStepStmt: %w", cerr)
+		}
+	}
+	if q.useVerifyEmailStmt != nil {
+		if cerr := q.useVerifyEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useVerifyEmailStmt: %w", cerr)
+		}
+	}
+	if q.verifyUserEmailStmt != nil {
+		if cerr := q.verifyUserEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing verifyUserEmailStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -250,59 +730,179 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                       DBTX
-	tx                       *sql.Tx
-	createAccountStmt        *sql.Stmt
-	createEntryStmt          *sql.Stmt
-	createSessionStmt        *sql.Stmt
-	createTransferStmt       *sql.Stmt
-	createUserStmt           *sql.Stmt
-	deleteAccountStmt        *sql.Stmt
-	deleteEntryStmt          *sql.Stmt
-	deleteTransferStmt       *sql.Stmt
-	deleteUserStmt           *sql.Stmt
-	getAccountStmt           *sql.Stmt
-	getAccountForUpdateStmt  *sql.Stmt
-	getEntryStmt             *sql.Stmt
-	getSessionStmt           *sql.Stmt
-	getTransferStmt          *sql.Stmt
-	getUserStmt              *sql.Stmt
-	getUserForUpdateStmt     *sql.Stmt
-	listAccountsStmt         *sql.Stmt
-	listEntriesStmt          *sql.Stmt
-	listTransfersStmt        *sql.Stmt
-	listUsersStmt            *sql.Stmt
-	updateAccountStmt        *sql.Stmt
-	updateAccountBalanceStmt *sql.Stmt
-	updateUserStmt           *sql.Stmt
+	db                                  DBTX
+	tx                                  *sql.Tx
+	blockSessionStmt                    *sql.Stmt
+	blockSessionFamilyStmt              *sql.Stmt
+	blockUserSessionsStmt               *sql.Stmt
+	claimDueWebhookDeliveriesStmt       *sql.Stmt
+	confirmTotpCredentialStmt           *sql.Stmt
+	consumeSessionStmt                  *sql.Stmt
+	createAccountStmt                   *sql.Stmt
+	createApiKeyStmt                    *sql.Stmt
+	createEntryStmt                     *sql.Stmt
+	createMfaChallengeStmt              *sql.Stmt
+	createMfaRecoveryCodeStmt           *sql.Stmt
+	createOauthAuthorizationCodeStmt    *sql.Stmt
+	createOauthClientStmt               *sql.Stmt
+	createPasswordResetTokenStmt        *sql.Stmt
+	createSessionStmt                   *sql.Stmt
+	createTransferStmt                  *sql.Stmt
+	createUserStmt                      *sql.Stmt
+	createVerifyEmailStmt               *sql.Stmt
+	createWebhookDeliveryStmt           *sql.Stmt
+	createWebhookSubscriptionStmt       *sql.Stmt
+	debitAccountBalanceStmt             *sql.Stmt
+	delayLoginStmt                      *sql.Stmt
+	deleteAccountStmt                   *sql.Stmt
+	deleteEntryStmt                     *sql.Stmt
+	deleteLoginFailureStmt              *sql.Stmt
+	deleteMfaRecoveryCodesStmt          *sql.Stmt
+	deleteTransferStmt                  *sql.Stmt
+	deleteUserStmt                      *sql.Stmt
+	deleteWebhookSubscriptionStmt       *sql.Stmt
+	forgetLoginAttemptStmt              *sql.Stmt
+	getAccountStmt                      *sql.Stmt
+	getAccountForUpdateStmt             *sql.Stmt
+	getApiKeyStmt                       *sql.Stmt
+	getApiKeyByPrefixStmt               *sql.Stmt
+	getEntryStmt                        *sql.Stmt
+	getMfaChallengeStmt                 *sql.Stmt
+	getOauthClientStmt                  *sql.Stmt
+	getOauthConsentStmt                 *sql.Stmt
+	getSessionStmt                      *sql.Stmt
+	getSessionForUpdateStmt             *sql.Stmt
+	getTotpCredentialStmt               *sql.Stmt
+	getTransferStmt                     *sql.Stmt
+	getUserStmt                         *sql.Stmt
+	getUserByEmailStmt                  *sql.Stmt
+	getUserForUpdateStmt                *sql.Stmt
+	getUserPasswordChangedAtStmt        *sql.Stmt
+	getWebhookDeliveryStmt              *sql.Stmt
+	getWebhookSubscriptionStmt          *sql.Stmt
+	incrementMfaChallengeAttemptsStmt   *sql.Stmt
+	invalidatePasswordResetTokensStmt   *sql.Stmt
+	listAccountActivityStmt             *sql.Stmt
+	listAccountsStmt                    *sql.Stmt
+	listActiveSessionsStmt              *sql.Stmt
+	listApiKeysStmt                     *sql.Stmt
+	listEntriesStmt                     *sql.Stmt
+	listLoginFailuresStmt               *sql.Stmt
+	listOauthConsentsStmt               *sql.Stmt
+	listTransfersStmt                   *sql.Stmt
+	listUsersStmt                       *sql.Stmt
+	listWebhookDeliveriesStmt           *sql.Stmt
+	listWebhookSubscriptionsStmt        *sql.Stmt
+	listWebhookSubscriptionsByEventStmt *sql.Stmt
+	notifyAccountActivityStmt           *sql.Stmt
+	recordLoginAttemptStmt              *sql.Stmt
+	replayWebhookDeliveryStmt           *sql.Stmt
+	revokeApiKeyStmt                    *sql.Stmt
+	revokeOauthConsentStmt              *sql.Stmt
+	setAccountFrozenStmt                *sql.Stmt
+	touchApiKeyStmt                     *sql.Stmt
+	updateAccountStmt                   *sql.Stmt
+	updateAccountBalanceStmt            *sql.Stmt
+	updateUserStmt                      *sql.Stmt
+	updateUserRoleStmt                  *sql.Stmt
+	updateWebhookDeliveryAttemptStmt    *sql.Stmt
+	upsertOauthConsentStmt              *sql.Stmt
+	upsertTotpCredentialStmt            *sql.Stmt
+	useMfaChallengeStmt                 *sql.Stmt
+	useMfaRecoveryCodeStmt              *sql.Stmt
+	useOauthAuthorizationCodeStmt       *sql.Stmt
+	usePasswordResetTokenStmt           *sql.Stmt
+	useTotpStepStmt                     *sql.Stmt
+	useVerifyEmailStmt                  *sql.Stmt
+	verifyUserEmailStmt                 *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                       tx,
-		tx:                       tx,
-		createAccountStmt:        q.createAccountStmt,
-		createEntryStmt:          q.createEntryStmt,
-		createSessionStmt:        q.createSessionStmt,
-		createTransferStmt:       q.createTransferStmt,
-		createUserStmt:           q.createUserStmt,
-		deleteAccountStmt:        q.deleteAccountStmt,
-		deleteEntryStmt:          q.deleteEntryStmt,
-		deleteTransferStmt:       q.deleteTransferStmt,
-		deleteUserStmt:           q.deleteUserStmt,
-		getAccountStmt:           q.getAccountStmt,
-		getAccountForUpdateStmt:  q.getAccountForUpdateStmt,
-		getEntryStmt:             q.getEntryStmt,
-		getSessionStmt:           q.getSessionStmt,
-		getTransferStmt:          q.getTransferStmt,
-		getUserStmt:              q.getUserStmt,
-		getUserForUpdateStmt:     q.getUserForUpdateStmt,
-		listAccountsStmt:         q.listAccountsStmt,
-		listEntriesStmt:          q.listEntriesStmt,
-		listTransfersStmt:        q.listTransfersStmt,
-		listUsersStmt:            q.listUsersStmt,
-		updateAccountStmt:        q.updateAccountStmt,
-		updateAccountBalanceStmt: q.updateAccountBalanceStmt,
-		updateUserStmt:           q.updateUserStmt,
+		db:                                  tx,
+		tx:                                  tx,
+		blockSessionStmt:                    q.blockSessionStmt,
+		blockSessionFamilyStmt:              q.blockSessionFamilyStmt,
+		blockUserSessionsStmt:               q.blockUserSessionsStmt,
+		claimDueWebhookDeliveriesStmt:       q.claimDueWebhookDeliveriesStmt,
+		confirmTotpCredentialStmt:           q.confirmTotpCredentialStmt,
+		consumeSessionStmt:                  q.consumeSessionStmt,
+		createAccountStmt:                   q.createAccountStmt,
+		createApiKeyStmt:                    q.createApiKeyStmt,
+		createEntryStmt:                     q.createEntryStmt,
+		createMfaChallengeStmt:              q.createMfaChallengeStmt,
+		createMfaRecoveryCodeStmt:           q.createMfaRecoveryCodeStmt,
+		createOauthAuthorizationCodeStmt:    q.createOauthAuthorizationCodeStmt,
+		createOauthClientStmt:               q.createOauthClientStmt,
+		createPasswordResetTokenStmt:        q.createPasswordResetTokenStmt,
+		createSessionStmt:                   q.createSessionStmt,
+		createTransferStmt:                  q.createTransferStmt,
+		createUserStmt:                      q.createUserStmt,
+		createVerifyEmailStmt:               q.createVerifyEmailStmt,
+		createWebhookDeliveryStmt:           q.createWebhookDeliveryStmt,
+		createWebhookSubscriptionStmt:       q.createWebhookSubscriptionStmt,
+		debitAccountBalanceStmt:             q.debitAccountBalanceStmt,
+		delayLoginStmt:                      q.delayLoginStmt,
+		deleteAccountStmt:                   q.deleteAccountStmt,
+		deleteEntryStmt:                     q.deleteEntryStmt,
+		deleteLoginFailureStmt:              q.deleteLoginFailureStmt,
+		deleteMfaRecoveryCodesStmt:          q.deleteMfaRecoveryCodesStmt,
+		deleteTransferStmt:                  q.deleteTransferStmt,
+		deleteUserStmt:                      q.deleteUserStmt,
+		deleteWebhookSubscriptionStmt:       q.deleteWebhookSubscriptionStmt,
+		forgetLoginAttemptStmt:              q.forgetLoginAttemptStmt,
+		getAccountStmt:                      q.getAccountStmt,
+		getAccountForUpdateStmt:             q.getAccountForUpdateStmt,
+		getApiKeyStmt:                       q.getApiKeyStmt,
+		getApiKeyByPrefixStmt:               q.getApiKeyByPrefixStmt,
+		getEntryStmt:                        q.getEntryStmt,
+		getMfaChallengeStmt:                 q.getMfaChallengeStmt,
+		getOauthClientStmt:                  q.getOauthClientStmt,
+		getOauthConsentStmt:                 q.getOauthConsentStmt,
+		getSessionStmt:                      q.getSessionStmt,
+		getSessionForUpdateStmt:             q.getSessionForUpdateStmt,
+		getTotpCredentialStmt:               q.getTotpCredentialStmt,
+		getTransferStmt:                     q.getTransferStmt,
+		getUserStmt:                         q.getUserStmt,
+		getUserByEmailStmt:                  q.getUserByEmailStmt,
+		getUserForUpdateStmt:                q.getUserForUpdateStmt,
+		getUserPasswordChangedAtStmt:        q.getUserPasswordChangedAtStmt,
+		getWebhookDeliveryStmt:              q.getWebhookDeliveryStmt,
+		getWebhookSubscriptionStmt:          q.getWebhookSubscriptionStmt,
+		incrementMfaChallengeAttemptsStmt:   q.incrementMfaChallengeAttemptsStmt,
+		invalidatePasswordResetTokensStmt:   q.invalidatePasswordResetTokensStmt,
+		listAccountActivityStmt:             q.listAccountActivityStmt,
+		listAccountsStmt:                    q.listAccountsStmt,
+		listActiveSessionsStmt:              q.listActiveSessionsStmt,
+		listApiKeysStmt:                     q.listApiKeysStmt,
+		listEntriesStmt:                     q.listEntriesStmt,
+		listLoginFailuresStmt:               q.listLoginFailuresStmt,
+		listOauthConsentsStmt:               q.listOauthConsentsStmt,
+		listTransfersStmt:                   q.listTransfersStmt,
+		listUsersStmt:                       q.listUsersStmt,
+		listWebhookDeliveriesStmt:           q.listWebhookDeliveriesStmt,
+		listWebhookSubscriptionsStmt:        q.listWebhookSubscriptionsStmt,
+		listWebhookSubscriptionsByEventStmt: q.listWebhookSubscriptionsByEventStmt,
+		notifyAccountActivityStmt:           q.notifyAccountActivityStmt,
+		recordLoginAttemptStmt:              q.recordLoginAttemptStmt,
+		replayWebhookDeliveryStmt:           q.replayWebhookDeliveryStmt,
+		revokeApiKeyStmt:                    q.revokeApiKeyStmt,
+		revokeOauthConsentStmt:              q.revokeOauthConsentStmt,
+		setAccountFrozenStmt:                q.setAccountFrozenStmt,
+		touchApiKeyStmt:                     q.touchApiKeyStmt,
+		updateAccountStmt:                   q.updateAccountStmt,
+		updateAccountBalanceStmt:            q.updateAccountBalanceStmt,
+		updateUserStmt:                      q.updateUserStmt,
+		updateUserRoleStmt:                  q.updateUserRoleStmt,
+		updateWebhookDeliveryAttemptStmt:    q.updateWebhookDeliveryAttemptStmt,
+		upsertOauthConsentStmt:              q.upsertOauthConsentStmt,
+		upsertTotpCredentialStmt:            q.upsertTotpCredentialStmt,
+		useMfaChallengeStmt:                 q.useMfaChallengeStmt,
+		useMfaRecoveryCodeStmt:              q.useMfaRecoveryCodeStmt,
+		useOauthAuthorizationCodeStmt:       q.useOauthAuthorizationCodeStmt,
+		usePasswordResetTokenStmt:           q.usePasswordResetTokenStmt,
+		useTotpStepStmt:                     q.useTotpStepStmt,
+		useVerifyEmailStmt:                  q.useVerifyEmailStmt,
+		verifyUserEmailStmt:                 q.verifyUserEmailStmt,
 	}
 }
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRecordLoginAttempt(t *testing.T) {
+	username := utils.RandomOwner()
+	arg := RecordLoginAttemptParams{
+		Scope:       "username",
+		Identifier:  username,
+		MaxFailures: 3,
+		LockUntil:   time.Now().Add(time.Minute),
+		WindowStart: time.Now().Add(-time.Minute),
+	}
+
+	failure, err := testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedCount)
+	require.False(t, failure.LockedUntil.Valid)
+
+	// attempts older than the window are not counted
+	arg.WindowStart = time.Now().Add(time.Minute)
+	failure, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedCount)
+
+	arg.WindowStart = time.Now().Add(-time.Minute)
+	failure, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failure.FailedCount)
+	require.False(t, failure.LockedUntil.Valid)
+
+	// the attempt that reaches the max failures locks the next ones out
+	failure, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), failure.FailedCount)
+	require.WithinDuration(t, arg.LockUntil, failure.LockedUntil.Time, time.Second)
+
+	_, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	failures, err := testQueries.ListLoginFailures(context.Background(), ListLoginFailuresParams{
+		Username: username,
+		ClientIp: utils.RandomString(10),
+	})
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, int32(3), failures[0].FailedCount)
+
+	err = testQueries.DeleteLoginFailure(context.Background(), DeleteLoginFailureParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	failures, err = testQueries.ListLoginFailures(context.Background(), ListLoginFailuresParams{
+		Username: username,
+	})
+	require.NoError(t, err)
+	require.Empty(t, failures)
+}
+
+func TestDelayLogin(t *testing.T) {
+	arg := RecordLoginAttemptParams{
+		Scope:       "username",
+		Identifier:  utils.RandomOwner(),
+		MaxFailures: 5,
+		LockUntil:   time.Now().Add(time.Minute),
+		WindowStart: time.Now().Add(-time.Minute),
+	}
+	_, err := testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	_, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+
+	// the second failure waits base * 2
+	err = testQueries.DelayLogin(context.Background(), DelayLoginParams{
+		BaseDelaySeconds: 10,
+		MaxDelaySeconds:  60,
+		Scope:            arg.Scope,
+		Identifier:       arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	failures, err := testQueries.ListLoginFailures(context.Background(), ListLoginFailuresParams{
+		Username: arg.Identifier,
+	})
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.WithinDuration(t, time.Now().Add(20*time.Second), failures[0].LockedUntil.Time, 2*time.Second)
+}
+
+func TestForgetLoginAttempt(t *testing.T) {
+	arg := RecordLoginAttemptParams{
+		Scope:       "client_ip",
+		Identifier:  utils.RandomString(10),
+		MaxFailures: 2,
+		LockUntil:   time.Now().Add(time.Minute),
+		WindowStart: time.Now().Add(-time.Minute),
+	}
+	_, err := testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	failure, err := testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, failure.LockedUntil.Valid)
+
+	// the successful attempt that reached the max doesn't lock the address
+	err = testQueries.ForgetLoginAttempt(context.Background(), ForgetLoginAttemptParams{
+		MaxFailures: arg.MaxFailures,
+		Scope:       arg.Scope,
+		Identifier:  arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	failures, err := testQueries.ListLoginFailures(context.Background(), ListLoginFailuresParams{
+		ClientIp: arg.Identifier,
+	})
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, int32(1), failures[0].FailedCount)
+	require.False(t, failures[0].LockedUntil.Valid)
+}
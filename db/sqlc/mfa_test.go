@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func CreateRandomTotpCredential(t *testing.T, user User) TotpCredential {
+	args := UpsertTotpCredentialParams{
+		Username: user.Username,
+		Secret:   utils.RandomString(32),
+	}
+
+	credential, err := testQueries.UpsertTotpCredential(context.Background(), args)
+	require.NoError(t, err)
+
+	require.Equal(t, args.Username, credential.Username)
+	require.Equal(t, args.Secret, credential.Secret)
+	require.False(t, credential.IsConfirmed)
+	require.False(t, credential.ConfirmedAt.Valid)
+	require.NotZero(t, credential.CreatedAt)
+
+	return credential
+}
+
+func TestUpsertTotpCredential(t *testing.T) {
+	user := CreateRandomUser(t)
+	credential1 := CreateRandomTotpCredential(t, user)
+
+	// enrolling again replaces the unconfirmed secret
+	credential2 := CreateRandomTotpCredential(t, user)
+	require.NotEqual(t, credential1.Secret, credential2.Secret)
+}
+
+func TestUseTotpStep(t *testing.T) {
+	user := CreateRandomUser(t)
+	credential := CreateRandomTotpCredential(t, user)
+	require.Zero(t, credential.LastUsedStep)
+
+	arg := UseTotpStepParams{Step: 100, Username: user.Username}
+	credential, err := testQueries.UseTotpStep(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Step, credential.LastUsedStep)
+
+	// the code of a step is accepted once, and the earlier steps aren't accepted anymore
+	_, err = testQueries.UseTotpStep(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.UseTotpStep(context.Background(), UseTotpStepParams{Step: 99, Username: user.Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestConfirmTotpTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := CreateRandomUser(t)
+	CreateRandomTotpCredential(t, user)
+
+	hashes := []string{utils.RandomString(64), utils.RandomString(64)}
+	result, err := store.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username:           user.Username,
+		RecoveryCodeHashes: hashes,
+	})
+	require.NoError(t, err)
+	require.True(t, result.TotpCredential.IsConfirmed)
+	require.True(t, result.TotpCredential.ConfirmedAt.Valid)
+	require.Len(t, result.RecoveryCodes, len(hashes))
+
+	// a confirmed secret can't be replaced
+	_, err = testQueries.UpsertTotpCredential(context.Background(), UpsertTotpCredentialParams{
+		Username: user.Username,
+		Secret:   utils.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// recovery codes are single-use
+	arg := UseMfaRecoveryCodeParams{Username: user.Username, CodeHash: hashes[0]}
+	code, err := testQueries.UseMfaRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, code.UsedAt.Valid)
+
+	_, err = testQueries.UseMfaRecoveryCode(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMfaChallenge(t *testing.T) {
+	user := CreateRandomUser(t)
+
+	challenge, err := testQueries.CreateMfaChallenge(context.Background(), CreateMfaChallengeParams{
+		Username:  user.Username,
+		TokenHash: utils.RandomString(64),
+		ExpiredAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, challenge.Attempts)
+	require.False(t, challenge.IsUsed)
+
+	challenge, err = testQueries.IncrementMfaChallengeAttempts(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), challenge.Attempts)
+
+	challenge, err = testQueries.UseMfaChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, challenge.IsUsed)
+
+	// challenges are single-use
+	_, err = testQueries.UseMfaChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -6,6 +6,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,20 @@ type Account struct {
 	Balance   int64        `json:"balance"`
 	Currency  string       `json:"currency"`
 	CreatedAt sql.NullTime `json:"created_at"`
+	IsFrozen  bool         `json:"is_frozen"`
+}
+
+type ApiKey struct {
+	ID         int64        `json:"id"`
+	Username   string       `json:"username"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	HashedKey  string       `json:"hashed_key"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
 }
 
 type Entry struct {
@@ -26,6 +41,73 @@ type Entry struct {
 	CreatedAt sql.NullTime `json:"created_at"`
 }
 
+type LoginFailure struct {
+	Scope        string       `json:"scope"`
+	Identifier   string       `json:"identifier"`
+	FailedCount  int32        `json:"failed_count"`
+	LastFailedAt time.Time    `json:"last_failed_at"`
+	LockedUntil  sql.NullTime `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	Attempts  int32     `json:"attempts"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type OauthAuthorizationCode struct {
+	HashedCode    string       `json:"hashed_code"`
+	ClientID      string       `json:"client_id"`
+	Username      string       `json:"username"`
+	RedirectUri   string       `json:"redirect_uri"`
+	Scopes        []string     `json:"scopes"`
+	CodeChallenge string       `json:"code_challenge"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	UsedAt        sql.NullTime `json:"used_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type OauthClient struct {
+	ID           int64     `json:"id"`
+	ClientID     string    `json:"client_id"`
+	HashedSecret string    `json:"hashed_secret"`
+	Name         string    `json:"name"`
+	Owner        string    `json:"owner"`
+	RedirectUris []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OauthConsent struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	ClientID  string       `json:"client_id"`
+	Scopes    []string     `json:"scopes"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID    `json:"id"`
 	Username     string       `json:"username"`
@@ -35,6 +117,17 @@ type Session struct {
 	IsBlocked    bool         `json:"is_blocked"`
 	ExpiresAt    sql.NullTime `json:"expires_at"`
 	CreatedAt    sql.NullTime `json:"created_at"`
+	FamilyID     uuid.UUID    `json:"family_id"`
+	ConsumedAt   sql.NullTime `json:"consumed_at"`
+}
+
+type TotpCredential struct {
+	Username     string       `json:"username"`
+	Secret       string       `json:"secret"`
+	IsConfirmed  bool         `json:"is_confirmed"`
+	CreatedAt    time.Time    `json:"created_at"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
 }
 
 type Transfer struct {
@@ -52,4 +145,40 @@ type User struct {
 	Email             string       `json:"email"`
 	PasswordChangedAt time.Time    `json:"password_changed_at"`
 	CreatedAt         sql.NullTime `json:"created_at"`
+	IsEmailVerified   bool         `json:"is_email_verified"`
+	Role              string       `json:"role"`
+}
+
+type VerifyEmail struct {
+	ID             int64     `json:"id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	SecretCodeHash string    `json:"secret_code_hash"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
+}
+
+type WebhookDelivery struct {
+	ID               int64           `json:"id"`
+	SubscriptionID   int64           `json:"subscription_id"`
+	EventType        string          `json:"event_type"`
+	Payload          json.RawMessage `json:"payload"`
+	Status           string          `json:"status"`
+	Attempts         int32           `json:"attempts"`
+	NextAttemptAt    time.Time       `json:"next_attempt_at"`
+	LastResponseCode sql.NullInt32   `json:"last_response_code"`
+	LastError        string          `json:"last_error"`
+	DeliveredAt      sql.NullTime    `json:"delivered_at"`
+	CreatedAt        sql.NullTime    `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID         int64        `json:"id"`
+	Owner      string       `json:"owner"`
+	Url        string       `json:"url"`
+	EventTypes []string     `json:"event_types"`
+	Secret     string       `json:"secret"`
+	IsActive   bool         `json:"is_active"`
+	CreatedAt  sql.NullTime `json:"created_at"`
 }
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomOauthClient(t *testing.T) OauthClient {
+	owner := CreateRandomUser(t)
+	args := CreateOauthClientParams{
+		ClientID:     utils.RandomString(32),
+		HashedSecret: utils.RandomString(64),
+		Name:         utils.RandomString(6),
+		Owner:        owner.Username,
+		RedirectUris: []string{"https://" + utils.RandomOwner() + ".example.com/callback"},
+		Scopes:       []string{"accounts:read"},
+	}
+
+	client, err := testQueries.CreateOauthClient(context.Background(), args)
+	require.NoError(t, err)
+
+	require.Equal(t, args.ClientID, client.ClientID)
+	require.Equal(t, args.HashedSecret, client.HashedSecret)
+	require.Equal(t, args.Owner, client.Owner)
+	require.Equal(t, args.RedirectUris, client.RedirectUris)
+	require.Equal(t, args.Scopes, client.Scopes)
+	require.NotZero(t, client.CreatedAt)
+
+	return client
+}
+
+func TestGetOauthClient(t *testing.T) {
+	client1 := createRandomOauthClient(t)
+
+	client2, err := testQueries.GetOauthClient(context.Background(), client1.ClientID)
+	require.NoError(t, err)
+	require.Equal(t, client1, client2)
+}
+
+func TestUpsertOauthConsent(t *testing.T) {
+	client := createRandomOauthClient(t)
+	user := CreateRandomUser(t)
+	args := UpsertOauthConsentParams{
+		Username: user.Username,
+		ClientID: client.ClientID,
+		Scopes:   []string{"accounts:read"},
+	}
+
+	consent1, err := testQueries.UpsertOauthConsent(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, args.Scopes, consent1.Scopes)
+
+	revoked, err := testQueries.RevokeOauthConsent(context.Background(), RevokeOauthConsentParams{
+		Username: user.Username,
+		ClientID: client.ClientID,
+	})
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	consents, err := testQueries.ListOauthConsents(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, consents)
+
+	// approving the client again replaces the revoked consent
+	consent2, err := testQueries.UpsertOauthConsent(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, consent1.ID, consent2.ID)
+	require.False(t, consent2.RevokedAt.Valid)
+
+	consents, err = testQueries.ListOauthConsents(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, consents, 1)
+}
+
+func TestUseOauthAuthorizationCode(t *testing.T) {
+	client := createRandomOauthClient(t)
+	user := CreateRandomUser(t)
+	args := CreateOauthAuthorizationCodeParams{
+		HashedCode:    utils.RandomString(64),
+		ClientID:      client.ClientID,
+		Username:      user.Username,
+		RedirectUri:   client.RedirectUris[0],
+		Scopes:        client.Scopes,
+		CodeChallenge: utils.RandomString(43),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	_, err := testQueries.CreateOauthAuthorizationCode(context.Background(), args)
+	require.NoError(t, err)
+
+	code, err := testQueries.UseOauthAuthorizationCode(context.Background(), args.HashedCode)
+	require.NoError(t, err)
+	require.True(t, code.UsedAt.Valid)
+	require.Equal(t, args.Username, code.Username)
+
+	// codes can only be used once
+	_, err = testQueries.UseOauthAuthorizationCode(context.Background(), args.HashedCode)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
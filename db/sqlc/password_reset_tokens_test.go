@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func CreateRandomPasswordResetToken(t *testing.T, user User, expiredAt time.Time) PasswordResetToken {
+	args := CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: utils.RandomString(64),
+		ExpiredAt: expiredAt,
+	}
+
+	resetToken, err := testQueries.CreatePasswordResetToken(context.Background(), args)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, resetToken)
+
+	require.Equal(t, args.Username, resetToken.Username)
+	require.Equal(t, args.TokenHash, resetToken.TokenHash)
+	require.False(t, resetToken.IsUsed)
+
+	require.NotZero(t, resetToken.ID)
+	require.NotZero(t, resetToken.CreatedAt)
+
+	return resetToken
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	CreateRandomPasswordResetToken(t, CreateRandomUser(t), time.Now().Add(time.Minute))
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := CreateRandomUser(t)
+	resetToken := CreateRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+	otherToken := CreateRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+
+	result, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      resetToken.TokenHash,
+		HashedPassword: "new_password",
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, "new_password", result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	// tokens are single-use
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      resetToken.TokenHash,
+		HashedPassword: "other_password",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the other tokens of the user were invalidated
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      otherToken.TokenHash,
+		HashedPassword: "other_password",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := CreateRandomUser(t)
+	resetToken := CreateRandomPasswordResetToken(t, user, time.Now().Add(-time.Minute))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      resetToken.TokenHash,
+		HashedPassword: "new_password",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -11,15 +11,20 @@ import (
 )
 
 func createRandomSession(t *testing.T) Session {
-	user := CreateRandomUser(t)
+	return createRandomUserSession(t, CreateRandomUser(t), utils.RandomString(6))
+}
+
+func createRandomUserSession(t *testing.T, user User, clientIP string) Session {
+	id := uuid.New()
 	args := CreateSessionParams{
-		ID:           uuid.New(),
+		ID:           id,
 		Username:     user.Username,
 		RefreshToken: utils.RandomString(32),
 		UserAgent:    utils.RandomString(6),
-		ClientIp:     utils.RandomString(6),
+		ClientIp:     clientIP,
 		IsBlocked:    false,
-		ExpiresAt:    sql.NullTime{},
+		ExpiresAt:    sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		FamilyID:     id,
 	}
 
 	session, err := testQueries.CreateSession(context.Background(), args)
@@ -56,3 +61,75 @@ func TestGetSession(t *testing.T) {
 	require.WithinDuration(t, s.CreatedAt.Time, session.CreatedAt.Time, time.Second)
 	require.WithinDuration(t, s.ExpiresAt.Time, session.ExpiresAt.Time, time.Second)
 }
+
+func TestListActiveSessions(t *testing.T) {
+	user := CreateRandomUser(t)
+	clientIP := utils.RandomString(6)
+
+	// devices behind the same NAT share the client IP
+	session1 := createRandomUserSession(t, user, clientIP)
+	session2 := createRandomUserSession(t, user, clientIP)
+
+	blocked, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session1.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	sessions, err := testQueries.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session2.ID, sessions[0].ID)
+}
+
+func TestBlockSessionOfOtherUser(t *testing.T) {
+	session := createRandomSession(t)
+	other := CreateRandomUser(t)
+
+	_, err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: other.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := NewStore(testDB)
+	session := createRandomSession(t)
+
+	newSession := func() CreateSessionParams {
+		return CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     session.Username,
+			RefreshToken: utils.RandomString(32),
+			UserAgent:    session.UserAgent,
+			ClientIp:     session.ClientIp,
+			ExpiresAt:    sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		}
+	}
+
+	result, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: newSession(),
+	})
+	require.NoError(t, err)
+	require.False(t, result.Reused)
+	require.Equal(t, session.FamilyID, result.Session.FamilyID)
+
+	consumed, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, consumed.ConsumedAt.Valid)
+
+	// presenting the consumed refresh token again revokes the whole family
+	reused, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: newSession(),
+	})
+	require.NoError(t, err)
+	require.True(t, reused.Reused)
+
+	rotated, err := testQueries.GetSession(context.Background(), result.Session.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.IsBlocked)
+}
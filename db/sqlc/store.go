@@ -3,12 +3,22 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error)
+	CreateUserTx(ctx context.Context, params CreateUserTxParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, params VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, params ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	ChangePasswordTx(ctx context.Context, params ChangePasswordTxParams) (ChangePasswordTxResult, error)
+	UpdateUserTx(ctx context.Context, params UpdateUserTxParams) (UpdateUserTxResult, error)
+	ConfirmTotpTx(ctx context.Context, params ConfirmTotpTxParams) (ConfirmTotpTxResult, error)
+	RotateSessionTx(ctx context.Context, params RotateSessionTxParams) (RotateSessionTxResult, error)
 }
 
 type (
@@ -20,6 +30,9 @@ type (
 		FromAccountID int64 `json:"from_account_id"`
 		ToAccountID   int64 `json:"to_account_id"`
 		Amount        int64 `json:"amount"`
+		// AfterTransfer runs within the transaction once the balances are updated, the transfer is rolled back if
+		// it fails. The webhook deliveries are stored with it so they're only queued with the transfer
+		AfterTransfer func(q Querier, result TransferTxResult) error `json:"-"`
 	}
 	TransferTxResult struct {
 		Transfer      Transfer `json:"transfer"`
@@ -27,6 +40,8 @@ type (
 		ToAccountID   Account  `json:"to_account_id"`
 		FromEntry     Entry    `json:"from_entry"`
 		ToEntry       Entry    `json:"to_entry"`
+		// Attempts counts the runs of the transaction, it's retried when Postgres aborts it
+		Attempts int `json:"-"`
 	}
 	BalanceTx struct {
 		AccountID1 int64
@@ -38,6 +53,12 @@ type (
 
 var txKey = struct{}{}
 
+// ErrInsufficientFunds is returned by TransferTx when the balance of the from account is lower than the amount
+var ErrInsufficientFunds = errors.New("account has insufficient funds")
+
+// maxTransferAttempts bounds the runs of TransferTx when Postgres aborts it to break a deadlock or a serialization conflict
+const maxTransferAttempts = 3
+
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
 		db:      db,
@@ -67,16 +88,33 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 }
 
 // TransferTx executes a query performing all the necessary db transactions involved in a transfer
-// It creates the transfer register, creates the account entries and updates the balance in both accounts within a single database transaction
-func (s *SQLStore) TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error) {
+// It creates the transfer register, creates the account entries and updates the balance in both accounts within a single database transaction.
+// Both entries are notified on AccountActivityChannel.
+// The from account is only debited while its balance covers the amount, otherwise it returns ErrInsufficientFunds.
+// The transaction runs again, up to maxTransferAttempts times, when Postgres aborts it to break a deadlock
+func (s *SQLStore) TransferTx(ctx context.Context, params TransferTxParams) (result TransferTxResult, err error) {
+	for result.Attempts < maxTransferAttempts {
+		attempts := result.Attempts + 1
+		result, err = s.transferTx(ctx, params)
+		result.Attempts = attempts
+		if !isRetryableTxError(err) {
+			break
+		}
+		log.Debug().Err(err).Int("attempt", attempts).Msg("retrying transfer transaction")
+	}
+
+	return result, err
+}
+
+func (s *SQLStore) transferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	txName := ctx.Value(txKey)
 
 	err := s.execTx(ctx, func(q *Queries) error {
 		var err error
-		fmt.Println(txName, "create transfer")
-		result.Transfer, err = s.CreateTransfer(ctx, CreateTransferParams{
+		log.Debug().Interface("tx", txName).Msg("create transfer")
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
 			FromAccountID: params.FromAccountID,
 			ToAccountID:   params.ToAccountID,
 			Amount:        params.Amount,
@@ -86,8 +124,8 @@ func (s *SQLStore) TransferTx(ctx context.Context, params TransferTxParams) (Tra
 			return err
 		}
 
-		fmt.Println(txName, "create first entry")
-		result.FromEntry, err = s.CreateEntry(ctx, CreateEntryParams{
+		log.Debug().Interface("tx", txName).Msg("create first entry")
+		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
 			Amount:    -params.Amount,
 			AccountID: params.FromAccountID,
 		})
@@ -96,8 +134,8 @@ func (s *SQLStore) TransferTx(ctx context.Context, params TransferTxParams) (Tra
 			return err
 		}
 
-		fmt.Println(txName, "create second entry")
-		result.ToEntry, err = s.CreateEntry(ctx, CreateEntryParams{
+		log.Debug().Interface("tx", txName).Msg("create second entry")
+		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
 			Amount:    params.Amount,
 			AccountID: params.ToAccountID,
 		})
@@ -128,29 +166,66 @@ func (s *SQLStore) TransferTx(ctx context.Context, params TransferTxParams) (Tra
 			}
 		}
 
-		return nil
+		if params.AfterTransfer != nil {
+			if err = params.AfterTransfer(q, result); err != nil {
+				return err
+			}
+		}
 
+		// notifications are delivered when the transaction commits and dropped if it rolls back
+		if err = publishAccountActivity(ctx, q, result.FromEntry, result.FromAccountID); err != nil {
+			return err
+		}
+		return publishAccountActivity(ctx, q, result.ToEntry, result.ToAccountID)
 	})
 
 	return result, err
 }
 
+// isRetryableTxError reports whether Postgres aborted the transaction to break a deadlock or a serialization conflict,
+// running it again can succeed
+func isRetryableTxError(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+	switch pqErr.Code.Name() {
+	case "deadlock_detected", "serialization_failure":
+		return true
+	}
+	return false
+}
+
 func modifyBalance(ctx context.Context, q *Queries, balance BalanceTx) (account1 Account, account2 Account, err error) {
-	account1, err = q.UpdateAccountBalance(ctx, UpdateAccountBalanceParams{
-		Amount: balance.Amount1,
-		ID:     balance.AccountID1,
-	})
+	account1, err = updateBalance(ctx, q, balance.AccountID1, balance.Amount1)
 	if err != nil {
 		return
 	}
 
-	account2, err = q.UpdateAccountBalance(ctx, UpdateAccountBalanceParams{
-		Amount: balance.Amount2,
-		ID:     balance.AccountID2,
-	})
+	account2, err = updateBalance(ctx, q, balance.AccountID2, balance.Amount2)
 	if err != nil {
 		return
 	}
 
 	return account1, account2, nil
 }
+
+// updateBalance adds the amount to the balance of the account. A debit is checked by the same statement, so
+// concurrent transfers can't both pass the check and leave the balance negative
+func updateBalance(ctx context.Context, q *Queries, accountID int64, amount int64) (Account, error) {
+	if amount >= 0 {
+		return q.UpdateAccountBalance(ctx, UpdateAccountBalanceParams{
+			Amount: amount,
+			ID:     accountID,
+		})
+	}
+
+	account, err := q.DebitAccountBalance(ctx, DebitAccountBalanceParams{
+		Amount: -amount,
+		ID:     accountID,
+	})
+	if err == sql.ErrNoRows {
+		return account, ErrInsufficientFunds
+	}
+	return account, err
+}
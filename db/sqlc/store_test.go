@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
+	"github.com/micaelapucciariello/simplebank/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -10,13 +13,14 @@ import (
 func TestTxStore(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := CreateRandomAccount(t)
+	amount := int64(10)
+	n := 10
+
+	// the from account covers every transfer
+	account1 := createAccountWithBalance(t, int64(n)*amount+utils.RandomBalance())
 	account2 := CreateRandomAccount(t)
 
 	fmt.Println(">> before transfer:", account1.Balance, account2.Balance)
-	amount := int64(10)
-
-	n := 10
 	exists := make(map[int]bool)
 
 	errs := make(chan error) // channels allow goroutines to communicate. connects concurrent goroutines.
@@ -133,14 +137,14 @@ func TestTxStore(t *testing.T) {
 func TestTxStoreDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := CreateRandomAccount(t)
-	account2 := CreateRandomAccount(t)
-
-	fmt.Println(">> before transfer:", account1.Balance, account2.Balance)
 	amount := int64(10)
-
 	n := 10 // 5 from account1 to account 2, 5 from account2 to account1
 
+	account1 := createAccountWithBalance(t, int64(n)*amount+utils.RandomBalance())
+	account2 := createAccountWithBalance(t, int64(n)*amount+utils.RandomBalance())
+
+	fmt.Println(">> before transfer:", account1.Balance, account2.Balance)
+
 	errs := make(chan error)
 
 	for i := 0; i < n; i++ {
@@ -188,3 +192,53 @@ func TestTxStoreDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTxStoreInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	n := 10
+	// the balance covers half of the concurrent transfers
+	account1 := createAccountWithBalance(t, int64(n/2)*amount)
+	account2 := CreateRandomAccount(t)
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	var failed int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == ErrInsufficientFunds {
+			failed++
+			continue
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, n/2, failed)
+
+	// the failed transfers rolled back, the balance never went below zero
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+int64(n/2)*amount, updatedAccount2.Balance)
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	require.True(t, isRetryableTxError(&pq.Error{Code: "40P01"}))
+	require.True(t, isRetryableTxError(&pq.Error{Code: "40001"}))
+	require.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryableTxError(sql.ErrConnDone))
+	require.False(t, isRetryableTxError(nil))
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	ChangePasswordTxParams struct {
+		Username       string
+		HashedPassword string
+	}
+	ChangePasswordTxResult struct {
+		User User `json:"user"`
+	}
+)
+
+// ChangePasswordTx stores the new password and blocks every session of the user within a single database transaction
+func (s *SQLStore) ChangePasswordTx(ctx context.Context, params ChangePasswordTxParams) (ChangePasswordTxResult, error) {
+	var result ChangePasswordTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = changePassword(ctx, q, params.Username, params.HashedPassword)
+		return err
+	})
+
+	return result, err
+}
+
+// changePassword updates hashed_password and password_changed_at, so tokens issued before are rejected, and blocks the user sessions
+func changePassword(ctx context.Context, q *Queries, username string, hashedPassword string) (User, error) {
+	user, err := q.GetUserForUpdate(ctx, username)
+	if err != nil {
+		return user, err
+	}
+
+	user, err = q.UpdateUser(ctx, UpdateUserParams{
+		Username:          user.Username,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		Email:             user.Email,
+		FullName:          user.FullName,
+	})
+	if err != nil {
+		return user, err
+	}
+
+	return user, q.BlockUserSessions(ctx, username)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+)
+
+type (
+	ConfirmTotpTxParams struct {
+		Username string
+		// RecoveryCodeHashes replace any recovery code generated before
+		RecoveryCodeHashes []string
+	}
+	ConfirmTotpTxResult struct {
+		TotpCredential TotpCredential    `json:"totp_credential"`
+		RecoveryCodes  []MfaRecoveryCode `json:"recovery_codes"`
+	}
+)
+
+// ConfirmTotpTx enables the TOTP credential of the user and stores its recovery codes within a single database transaction
+func (s *SQLStore) ConfirmTotpTx(ctx context.Context, params ConfirmTotpTxParams) (ConfirmTotpTxResult, error) {
+	var result ConfirmTotpTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.TotpCredential, err = q.ConfirmTotpCredential(ctx, params.Username)
+		if err != nil {
+			return err
+		}
+
+		if err = q.DeleteMfaRecoveryCodes(ctx, params.Username); err != nil {
+			return err
+		}
+
+		for _, codeHash := range params.RecoveryCodeHashes {
+			code, err := q.CreateMfaRecoveryCode(ctx, CreateMfaRecoveryCodeParams{
+				Username: params.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	CreateUserTxParams struct {
+		CreateUserParams
+		// VerifyEmailSecretCodeHash is the hash of the code mailed to the user, the code itself is not stored
+		VerifyEmailSecretCodeHash string
+		VerifyEmailExpiredAt      time.Time
+	}
+	CreateUserTxResult struct {
+		User        User        `json:"user"`
+		VerifyEmail VerifyEmail `json:"verify_email"`
+	}
+)
+
+// CreateUserTx creates the user together with its pending email verification within a single database transaction
+func (s *SQLStore) CreateUserTx(ctx context.Context, params CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, params.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:       result.User.Username,
+			Email:          result.User.Email,
+			SecretCodeHash: params.VerifyEmailSecretCodeHash,
+			ExpiredAt:      params.VerifyEmailExpiredAt,
+		})
+		return err
+	})
+
+	return result, err
+}
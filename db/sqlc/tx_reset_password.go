@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+)
+
+type (
+	ResetPasswordTxParams struct {
+		TokenHash      string
+		HashedPassword string
+	}
+	ResetPasswordTxResult struct {
+		User User `json:"user"`
+	}
+)
+
+// ResetPasswordTx consumes the reset token, invalidates the other reset tokens of the user, stores the new password
+// and blocks every session of the user within a single database transaction.
+// It returns sql.ErrNoRows if the token doesn't exist, was already used or is expired
+func (s *SQLStore) ResetPasswordTx(ctx context.Context, params ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, params.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		// the links of earlier requests can't change the password again
+		if err = q.InvalidatePasswordResetTokens(ctx, resetToken.Username); err != nil {
+			return err
+		}
+
+		result.User, err = changePassword(ctx, q, resetToken.Username, params.HashedPassword)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type (
+	RotateSessionTxParams struct {
+		SessionID uuid.UUID
+		// NewSession is created in the family of the consumed session, its FamilyID is ignored
+		NewSession CreateSessionParams
+	}
+	RotateSessionTxResult struct {
+		Session Session `json:"session"`
+		// Reused reports that the session had already been consumed, the whole family was blocked and no session was created
+		Reused bool `json:"reused"`
+	}
+)
+
+// RotateSessionTx consumes the session of a refresh token and creates the next one of its family within a single database transaction.
+// Presenting a consumed refresh token again means it was stolen, so the transaction blocks every session of the family instead
+func (s *SQLStore) RotateSessionTx(ctx context.Context, params RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// the lock makes concurrent renewals with the same token wait, so only one of them can consume it
+		session, err := q.GetSessionForUpdate(ctx, params.SessionID)
+		if err != nil {
+			return err
+		}
+
+		if session.ConsumedAt.Valid {
+			result.Reused = true
+			return q.BlockSessionFamily(ctx, session.FamilyID)
+		}
+
+		if _, err = q.ConsumeSession(ctx, session.ID); err != nil {
+			return err
+		}
+
+		arg := params.NewSession
+		arg.FamilyID = session.FamilyID
+		result.Session, err = q.CreateSession(ctx, arg)
+		return err
+	})
+
+	return result, err
+}
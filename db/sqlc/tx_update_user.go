@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type (
+	// UpdateUserTxParams only changes the fields that are valid
+	UpdateUserTxParams struct {
+		Username       string
+		FullName       sql.NullString
+		Email          sql.NullString
+		HashedPassword sql.NullString
+		// a new email must be verified again, VerifyEmailSecretCodeHash and VerifyEmailExpiredAt are used for its verification
+		VerifyEmailSecretCodeHash string
+		VerifyEmailExpiredAt      time.Time
+	}
+	UpdateUserTxResult struct {
+		User User `json:"user"`
+		// VerifyEmail is the pending verification of the new email, nil if the email didn't change
+		VerifyEmail *VerifyEmail `json:"verify_email"`
+	}
+)
+
+// UpdateUserTx applies a partial update of the user within a single database transaction.
+// A new password blocks every session like ChangePasswordTx, a new email resets its verification
+func (s *SQLStore) UpdateUserTx(ctx context.Context, params UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, params.Username)
+		if err != nil {
+			return err
+		}
+
+		arg := UpdateUserParams{
+			Username:          user.Username,
+			HashedPassword:    user.HashedPassword,
+			PasswordChangedAt: user.PasswordChangedAt,
+			Email:             user.Email,
+			FullName:          user.FullName,
+		}
+		if params.FullName.Valid {
+			arg.FullName = params.FullName.String
+		}
+		if params.Email.Valid {
+			arg.Email = params.Email.String
+		}
+		if params.HashedPassword.Valid {
+			arg.HashedPassword = params.HashedPassword.String
+			arg.PasswordChangedAt = time.Now()
+		}
+
+		result.User, err = q.UpdateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if params.HashedPassword.Valid {
+			if err = q.BlockUserSessions(ctx, user.Username); err != nil {
+				return err
+			}
+		}
+
+		if result.User.Email == user.Email {
+			return nil
+		}
+
+		verifyEmail, err := q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:       result.User.Username,
+			Email:          result.User.Email,
+			SecretCodeHash: params.VerifyEmailSecretCodeHash,
+			ExpiredAt:      params.VerifyEmailExpiredAt,
+		})
+		if err != nil {
+			return err
+		}
+		result.VerifyEmail = &verifyEmail
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+)
+
+type (
+	VerifyEmailTxParams struct {
+		EmailID        int64
+		SecretCodeHash string
+	}
+	VerifyEmailTxResult struct {
+		User        User        `json:"user"`
+		VerifyEmail VerifyEmail `json:"verify_email"`
+	}
+)
+
+// VerifyEmailTx consumes the verification code and flags the user email as verified within a single database transaction.
+// It returns sql.ErrNoRows if the code doesn't exist, was already used or is expired
+func (s *SQLStore) VerifyEmailTx(ctx context.Context, params VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:             params.EmailID,
+			SecretCodeHash: params.SecretCodeHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: result.VerifyEmail.Username,
+			Email:    result.VerifyEmail.Email,
+		})
+		return err
+	})
+
+	return result, err
+}
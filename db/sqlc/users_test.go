@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/micaelapucciariello/simplebank/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -71,6 +72,18 @@ func TestUpdateUser(t *testing.T) {
 	require.WithinDuration(t, u.CreatedAt.Time, user.CreatedAt.Time, time.Second)
 }
 
+func TestUpdateUserRole(t *testing.T) {
+	u := CreateRandomUser(t)
+	require.Equal(t, utils.CustomerRole, u.Role)
+
+	user, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: u.Username,
+		Role:     utils.BankerRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.BankerRole, user.Role)
+}
+
 func TestDeleteUser(t *testing.T) {
 	u := CreateRandomUser(t)
 	err := testQueries.DeleteUser(context.Background(), u.Username)
@@ -80,3 +93,62 @@ func TestDeleteUser(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, emptyAccount)
 }
+
+func TestChangePasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	u := CreateRandomUser(t)
+
+	result, err := store.ChangePasswordTx(context.Background(), ChangePasswordTxParams{
+		Username:       u.Username,
+		HashedPassword: "new_password",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new_password", result.User.HashedPassword)
+
+	changedAt, err := testQueries.GetUserPasswordChangedAt(context.Background(), u.Username)
+	require.NoError(t, err)
+	require.True(t, changedAt.After(u.PasswordChangedAt))
+	require.WithinDuration(t, result.User.PasswordChangedAt, changedAt, time.Second)
+}
+
+func TestUpdateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	u := CreateRandomUser(t)
+
+	u, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		Username: u.Username,
+		Email:    u.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, u.IsEmailVerified)
+
+	// only the full name changes, the email stays verified
+	fullName := utils.RandomOwner()
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: u.Username,
+		FullName: sql.NullString{String: fullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, fullName, result.User.FullName)
+	require.Equal(t, u.Email, result.User.Email)
+	require.Equal(t, u.HashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.IsEmailVerified)
+	require.Nil(t, result.VerifyEmail)
+
+	email := utils.RandomEmail()
+	result, err = store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username:                  u.Username,
+		Email:                     sql.NullString{String: email, Valid: true},
+		HashedPassword:            sql.NullString{String: "new_password", Valid: true},
+		VerifyEmailSecretCodeHash: utils.HashSecret(utils.RandomString(32)),
+		VerifyEmailExpiredAt:      time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, email, result.User.Email)
+	require.Equal(t, fullName, result.User.FullName)
+	require.Equal(t, "new_password", result.User.HashedPassword)
+	require.False(t, result.User.IsEmailVerified)
+	require.True(t, result.User.PasswordChangedAt.After(u.PasswordChangedAt))
+	require.NotNil(t, result.VerifyEmail)
+	require.Equal(t, email, result.VerifyEmail.Email)
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func CreateRandomVerifyEmail(t *testing.T, user User, expiredAt time.Time) VerifyEmail {
+	args := CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: utils.HashSecret(utils.RandomString(32)),
+		ExpiredAt:      expiredAt,
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), args)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, verifyEmail)
+
+	require.Equal(t, args.Username, verifyEmail.Username)
+	require.Equal(t, args.Email, verifyEmail.Email)
+	require.Equal(t, args.SecretCodeHash, verifyEmail.SecretCodeHash)
+	require.False(t, verifyEmail.IsUsed)
+
+	require.NotZero(t, verifyEmail.ID)
+	require.NotZero(t, verifyEmail.CreatedAt)
+
+	return verifyEmail
+}
+
+func TestCreateVerifyEmail(t *testing.T) {
+	CreateRandomVerifyEmail(t, CreateRandomUser(t), time.Now().Add(time.Minute))
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := CreateRandomUser(t)
+	require.False(t, user.IsEmailVerified)
+
+	v := CreateRandomVerifyEmail(t, user, time.Now().Add(time.Minute))
+
+	result, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        v.ID,
+		SecretCodeHash: v.SecretCodeHash,
+	})
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, user.Username, result.User.Username)
+
+	// codes are single-use
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        v.ID,
+		SecretCodeHash: v.SecretCodeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := CreateRandomUser(t)
+
+	v := CreateRandomVerifyEmail(t, user, time.Now().Add(-time.Minute))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        v.ID,
+		SecretCodeHash: v.SecretCodeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
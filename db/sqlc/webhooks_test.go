@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func CreateRandomWebhookSubscription(t *testing.T) WebhookSubscription {
+	user := CreateRandomUser(t)
+	args := CreateWebhookSubscriptionParams{
+		Owner:      user.Username,
+		Url:        "https://" + utils.RandomOwner() + ".example.com/hooks",
+		EventTypes: []string{"transfer.received"},
+		Secret:     utils.RandomString(32),
+	}
+
+	subscription, err := testQueries.CreateWebhookSubscription(context.Background(), args)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, subscription)
+
+	require.Equal(t, args.Owner, subscription.Owner)
+	require.Equal(t, args.Url, subscription.Url)
+	require.Equal(t, args.EventTypes, subscription.EventTypes)
+	require.Equal(t, args.Secret, subscription.Secret)
+	require.True(t, subscription.IsActive)
+
+	require.NotZero(t, subscription.CreatedAt)
+	require.NotZero(t, subscription.ID)
+
+	return subscription
+}
+
+func CreateRandomWebhookDelivery(t *testing.T, subscription WebhookSubscription) WebhookDelivery {
+	args := CreateWebhookDeliveryParams{
+		SubscriptionID: subscription.ID,
+		EventType:      subscription.EventTypes[0],
+		Payload:        json.RawMessage(`{"amount":10}`),
+	}
+
+	delivery, err := testQueries.CreateWebhookDelivery(context.Background(), args)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, delivery)
+
+	require.Equal(t, args.SubscriptionID, delivery.SubscriptionID)
+	require.Equal(t, args.EventType, delivery.EventType)
+	require.JSONEq(t, string(args.Payload), string(delivery.Payload))
+	require.Equal(t, "pending", delivery.Status)
+	require.Zero(t, delivery.Attempts)
+
+	require.NotZero(t, delivery.ID)
+
+	return delivery
+}
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	CreateRandomWebhookSubscription(t)
+}
+
+func TestListWebhookSubscriptionsByEvent(t *testing.T) {
+	s := CreateRandomWebhookSubscription(t)
+
+	args := ListWebhookSubscriptionsByEventParams{
+		Owner:     s.Owner,
+		EventType: s.EventTypes[0],
+	}
+
+	subscriptions, err := testQueries.ListWebhookSubscriptionsByEvent(context.Background(), args)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	require.Equal(t, s.ID, subscriptions[0].ID)
+
+	args.EventType = "account.created"
+	subscriptions, err = testQueries.ListWebhookSubscriptionsByEvent(context.Background(), args)
+	require.NoError(t, err)
+	require.Empty(t, subscriptions)
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	s := CreateRandomWebhookSubscription(t)
+	d := CreateRandomWebhookDelivery(t, s)
+
+	err := testQueries.DeleteWebhookSubscription(context.Background(), s.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetWebhookSubscription(context.Background(), s.ID)
+	require.Error(t, err)
+
+	_, err = testQueries.GetWebhookDelivery(context.Background(), d.ID)
+	require.Error(t, err)
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	s := CreateRandomWebhookSubscription(t)
+	d := CreateRandomWebhookDelivery(t, s)
+
+	args := UpdateWebhookDeliveryAttemptParams{
+		ID:            d.ID,
+		Status:        "dead",
+		Attempts:      8,
+		NextAttemptAt: time.Now(),
+		LastError:     "connection refused",
+	}
+
+	dead, err := testQueries.UpdateWebhookDeliveryAttempt(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, args.Status, dead.Status)
+	require.Equal(t, args.Attempts, dead.Attempts)
+	require.Equal(t, args.LastError, dead.LastError)
+
+	delivery, err := testQueries.ReplayWebhookDelivery(context.Background(), d.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", delivery.Status)
+	require.Zero(t, delivery.Attempts)
+	require.Empty(t, delivery.LastError)
+}
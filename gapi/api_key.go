@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/apikey"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/rs/zerolog/log"
+)
+
+// apiKeyScopes lists the methods API keys and OAuth apps can call and the scope each one needs,
+// every other method only accepts access tokens of users
+var apiKeyScopes = map[string]string{
+	pb.SimpleBank_CreateAccount_FullMethodName:        apikey.ScopeAccountsWrite,
+	pb.SimpleBank_GetAccount_FullMethodName:           apikey.ScopeAccountsRead,
+	pb.SimpleBank_ListAccounts_FullMethodName:         apikey.ScopeAccountsRead,
+	pb.SimpleBank_DeleteAccount_FullMethodName:        apikey.ScopeAccountsWrite,
+	pb.SimpleBank_WatchAccountActivity_FullMethodName: apikey.ScopeAccountsRead,
+
+	pb.SimpleBank_CreateTransfer_FullMethodName: apikey.ScopeTransfersWrite,
+
+	pb.SimpleBank_ListWebhookSubscriptions_FullMethodName:  apikey.ScopeWebhooksRead,
+	pb.SimpleBank_ListWebhookDeliveries_FullMethodName:     apikey.ScopeWebhooksRead,
+	pb.SimpleBank_CreateWebhookSubscription_FullMethodName: apikey.ScopeWebhooksWrite,
+	pb.SimpleBank_DeleteWebhookSubscription_FullMethodName: apikey.ScopeWebhooksWrite,
+	pb.SimpleBank_ReplayWebhookDelivery_FullMethodName:     apikey.ScopeWebhooksWrite,
+}
+
+var errApiKeyNotAccepted = errors.New("api keys and oauth apps are not accepted for this method")
+
+// authorizeApiKey returns the payload of the key if it's valid and has the scope the method needs,
+// every use of a key is logged
+func (s *Server) authorizeApiKey(ctx context.Context, method string, key string) (*token.Payload, error) {
+	if _, ok := apiKeyScopes[method]; !ok {
+		return nil, PermissionDeniedError(errApiKeyNotAccepted)
+	}
+
+	apiKey, err := s.apiKeys.Authenticate(ctx, key)
+	if err != nil {
+		return nil, UnauthenticatedError(fmt.Errorf("invalid api key: %w", err))
+	}
+
+	payload := apikey.NewPayload(apiKey)
+	if err = authorizeScope(method, payload); err != nil {
+		return nil, PermissionDeniedError(err)
+	}
+
+	log.Info().
+		Str("api_key", apiKey.Prefix).
+		Str("api_key_name", apiKey.Name).
+		Str("user", apiKey.Username).
+		Str("method", method).
+		Msg("api key used")
+	logging.SetUser(ctx, apiKey.Username)
+	return payload, nil
+}
+
+// authorizeScope returns errApiKeyNotAccepted unless the method accepts scoped credentials and the payload has its scope
+func authorizeScope(method string, payload *token.Payload) error {
+	scope, ok := apiKeyScopes[method]
+	if !ok {
+		return errApiKeyNotAccepted
+	}
+
+	if !payload.HasScope(scope) {
+		return fmt.Errorf("%w: credentials don't have the %s scope", errApiKeyNotAccepted, scope)
+	}
+	return nil
+}
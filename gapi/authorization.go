@@ -0,0 +1,112 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/micaelapucciariello/simplebank/oauth"
+	"github.com/micaelapucciariello/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+	authorizationApiKey = "apikey"
+)
+
+type payloadKey struct{}
+
+// authorizeUser returns the payload the auth interceptors put on the context of protected methods
+func (s *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	payload, ok := ctx.Value(payloadKey{}).(*token.Payload)
+	if !ok {
+		return nil, UnauthenticatedError(fmt.Errorf("missing credentials"))
+	}
+	return payload, nil
+}
+
+// reauthorize checks again the credentials of a stream that outlives the call of the interceptor: the access
+// token must not be older than the last password change and OAuth apps must keep the consent of the user
+func (s *Server) reauthorize(ctx context.Context, payload *token.Payload) error {
+	if payload.APIKeyID != 0 {
+		if err := s.apiKeys.Check(ctx, payload); err != nil {
+			return UnauthenticatedError(fmt.Errorf("invalid api key: %w", err))
+		}
+		return nil
+	}
+
+	if err := s.passwordChanges.Check(ctx, payload); err != nil {
+		return UnauthenticatedError(fmt.Errorf("invalid access token: %s", err))
+	}
+
+	if payload.Audience != "" {
+		if err := s.oauth.CheckGrant(ctx, payload); err != nil {
+			if errors.Is(err, oauth.ErrGrantRevoked) {
+				return UnauthenticatedError(fmt.Errorf("invalid access token: %s", err))
+			}
+			return status.Errorf(codes.Internal, "error checking oauth grant: %s", err)
+		}
+	}
+	return nil
+}
+
+// authenticate verifies the access token or API key sent in the authorization metadata for the method.
+// grpc-gateway forwards the HTTP Authorization header under the same key
+func (s *Server) authenticate(ctx context.Context, method string) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, UnauthenticatedError(fmt.Errorf("missing metadata"))
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, UnauthenticatedError(fmt.Errorf("authorization header not provided"))
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, UnauthenticatedError(fmt.Errorf("invalid authorization header format"))
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType == authorizationApiKey {
+		return s.authorizeApiKey(ctx, method, fields[1])
+	}
+	if authorizationType != authorizationBearer {
+		return nil, UnauthenticatedError(fmt.Errorf("invalid authorization type: %v", fields[0]))
+	}
+
+	payload, err := s.token.VerifyToken(fields[1])
+	if err == nil {
+		err = payload.CheckType(token.AccessToken)
+	}
+	if err != nil {
+		return nil, UnauthenticatedError(fmt.Errorf("invalid access token: %s", err))
+	}
+
+	// tokens issued before a password change are no longer valid
+	if err = s.passwordChanges.Check(ctx, payload); err != nil {
+		return nil, UnauthenticatedError(fmt.Errorf("invalid access token: %s", err))
+	}
+
+	// tokens of OAuth apps reach the same methods as API keys, while the user keeps its consent
+	if payload.Audience != "" {
+		if err = authorizeScope(method, payload); err != nil {
+			return nil, PermissionDeniedError(err)
+		}
+		if err = s.oauth.CheckGrant(ctx, payload); err != nil {
+			if errors.Is(err, oauth.ErrGrantRevoked) {
+				return nil, UnauthenticatedError(fmt.Errorf("invalid access token: %s", err))
+			}
+			return nil, status.Errorf(codes.Internal, "error checking oauth grant: %s", err)
+		}
+	}
+
+	logging.SetUser(ctx, payload.UserName)
+	return payload, nil
+}
@@ -13,5 +13,77 @@ func convertUser(user db.User) *pb.User {
 		Email:             user.Email,
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 		CreatedAt:         timestamppb.New(user.CreatedAt.Time),
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
+	}
+}
+
+func convertWebhookSubscription(subscription db.WebhookSubscription) *pb.WebhookSubscription {
+	return &pb.WebhookSubscription{
+		Id:         subscription.ID,
+		Url:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		IsActive:   subscription.IsActive,
+		CreatedAt:  timestamppb.New(subscription.CreatedAt.Time),
+	}
+}
+
+func convertWebhookDelivery(delivery db.WebhookDelivery) *pb.WebhookDelivery {
+	rsp := &pb.WebhookDelivery{
+		Id:               delivery.ID,
+		SubscriptionId:   delivery.SubscriptionID,
+		EventType:        delivery.EventType,
+		Payload:          string(delivery.Payload),
+		Status:           delivery.Status,
+		Attempts:         delivery.Attempts,
+		LastResponseCode: delivery.LastResponseCode.Int32,
+		LastError:        delivery.LastError,
+		NextAttemptAt:    timestamppb.New(delivery.NextAttemptAt),
+		CreatedAt:        timestamppb.New(delivery.CreatedAt.Time),
+	}
+	if delivery.DeliveredAt.Valid {
+		rsp.DeliveredAt = timestamppb.New(delivery.DeliveredAt.Time)
+	}
+	return rsp
+}
+
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID.String(),
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: timestamppb.New(session.CreatedAt.Time),
+		ExpiresAt: timestamppb.New(session.ExpiresAt.Time),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:        account.ID,
+		Owner:     account.Owner,
+		Balance:   account.Balance,
+		Currency:  account.Currency,
+		IsFrozen:  account.IsFrozen,
+		CreatedAt: timestamppb.New(account.CreatedAt.Time),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt.Time),
+	}
+}
+
+func convertAccountActivity(event db.AccountActivity) *pb.AccountActivity {
+	return &pb.AccountActivity{
+		EntryId:   event.EntryID,
+		AccountId: event.AccountID,
+		Amount:    event.Amount,
+		Balance:   event.Balance,
+		CreatedAt: timestamppb.New(event.CreatedAt),
 	}
 }
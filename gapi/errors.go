@@ -1,9 +1,27 @@
 package gapi
 
 import (
+	"fmt"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"time"
+)
+
+// errorDomain identifies this service in the ErrorInfo details of domain failures
+const errorDomain = "simplebank"
+
+// Reasons of domain failures, clients can match on them instead of parsing messages
+const (
+	ReasonAccountNotFound     = "ACCOUNT_NOT_FOUND"
+	ReasonAccountNotOwned     = "ACCOUNT_NOT_OWNED"
+	ReasonAccountFrozen       = "ACCOUNT_FROZEN"
+	ReasonCurrencyMismatch    = "CURRENCY_MISMATCH"
+	ReasonInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	ReasonEmailNotVerified    = "EMAIL_NOT_VERIFIED"
+	ReasonEmailVerified       = "EMAIL_ALREADY_VERIFIED"
+	ReasonTransferMfaRequired = "TRANSFER_MFA_REQUIRED"
 )
 
 func ViolationErr(field string, desc string) *errdetails.BadRequest_FieldViolation {
@@ -24,3 +42,73 @@ func InvalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) er
 
 	return statusDetails.Err()
 }
+
+func UnauthenticatedError(err error) error {
+	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+}
+
+func PermissionDeniedError(err error) error {
+	return status.Errorf(codes.PermissionDenied, "forbidden: %s", err)
+}
+
+// LockedError tells the client when it can try again, grpc-gateway maps it to 429 Too Many Requests
+func LockedError(err error, retryAfter time.Duration) error {
+	statusLocked := status.New(codes.ResourceExhausted, err.Error())
+
+	statusDetails, detailsErr := statusLocked.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+	if detailsErr != nil {
+		return statusLocked.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// DomainError carries an ErrorInfo with the reason of the failure and the resources involved
+func DomainError(code codes.Code, reason string, err error, metadata map[string]string) error {
+	statusDomain := status.New(code, err.Error())
+
+	statusDetails, detailsErr := statusDomain.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: metadata,
+	})
+	if detailsErr != nil {
+		return statusDomain.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+func PreconditionViolation(violationType string, subject string, desc string) *errdetails.PreconditionFailure_Violation {
+	return &errdetails.PreconditionFailure_Violation{
+		Type:        violationType,
+		Subject:     subject,
+		Description: desc,
+	}
+}
+
+// FailedPreconditionError is a DomainError that also lists the preconditions the request didn't meet
+func FailedPreconditionError(reason string, err error, metadata map[string]string, violations ...*errdetails.PreconditionFailure_Violation) error {
+	statusFailed := status.New(codes.FailedPrecondition, err.Error())
+
+	statusDetails, detailsErr := statusFailed.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason:   reason,
+			Domain:   errorDomain,
+			Metadata: metadata,
+		},
+		&errdetails.PreconditionFailure{Violations: violations},
+	)
+	if detailsErr != nil {
+		return statusFailed.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// accountSubject names an account in the details of domain failures
+func accountSubject(id int64) string {
+	return fmt.Sprintf("accounts/%d", id)
+}
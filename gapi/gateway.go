@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
+	"net/http"
+)
+
+// Gateway translates the REST calls into gRPC calls to an in-process gRPC server, so they go through the same
+// interceptors as the calls of gRPC clients: every method not in publicMethods is protected over HTTP too
+type Gateway struct {
+	mux        *runtime.ServeMux
+	conn       *grpc.ClientConn
+	grpcServer *grpc.Server
+	listener   *gatewayListener
+}
+
+// NewGateway routes the REST calls to grpcServer over an in-process listener, Serve serves them until grpcServer
+// is stopped and Close releases the connection afterwards
+func NewGateway(ctx context.Context, grpcServer *grpc.Server) (*Gateway, error) {
+	listener := newGatewayListener()
+
+	conn, err := grpc.DialContext(ctx, "gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect gateway to gRPC server: %w", err)
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithMetadata(GatewayRoute),
+		runtime.WithMetadata(GatewayTracing),
+		runtime.WithMetadata(gatewayRequestID),
+	)
+	if err = pb.RegisterSimpleBankHandler(ctx, mux, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot create gateway handler: %w", err)
+	}
+
+	return &Gateway{mux: mux, conn: conn, grpcServer: grpcServer, listener: listener}, nil
+}
+
+// Serve serves the calls of the gateway on grpcServer, it returns nil once grpcServer is stopped
+func (g *Gateway) Serve() error {
+	if err := g.grpcServer.Serve(g.listener); err != nil {
+		return fmt.Errorf("gateway gRPC server failed: %w", err)
+	}
+	return nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
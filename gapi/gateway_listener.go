@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// gatewayAddr is the address of both ends of the in-process connections between the gateway and its gRPC server.
+// Only this package creates it, so a peer with this address is the gateway
+type gatewayAddr struct{}
+
+func (gatewayAddr) Network() string { return "gateway" }
+func (gatewayAddr) String() string  { return "gateway" }
+
+// gatewayConn is one end of an in-process connection, it reports gatewayAddr instead of the address of the pipe
+type gatewayConn struct {
+	net.Conn
+}
+
+func (gatewayConn) LocalAddr() net.Addr  { return gatewayAddr{} }
+func (gatewayConn) RemoteAddr() net.Addr { return gatewayAddr{} }
+
+// gatewayListener is the in-process listener of the gRPC server of the gateway, every dial is a net.Pipe
+type gatewayListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newGatewayListener() *gatewayListener {
+	return &gatewayListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *gatewayListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *gatewayListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *gatewayListener) Addr() net.Addr {
+	return gatewayAddr{}
+}
+
+// DialContext connects to the listener, it waits until the connection is accepted
+func (l *gatewayListener) DialContext(ctx context.Context) (net.Conn, error) {
+	serverConn, clientConn := net.Pipe()
+
+	select {
+	case l.conns <- gatewayConn{serverConn}:
+		return gatewayConn{clientConn}, nil
+	case <-l.done:
+		serverConn.Close()
+		clientConn.Close()
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		serverConn.Close()
+		clientConn.Close()
+		return nil, ctx.Err()
+	}
+}
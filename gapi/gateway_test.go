@@ -0,0 +1,116 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestGateway serves the server behind the auth interceptor and the given interceptors, like main does
+func newTestGateway(t *testing.T, server *Server, interceptors ...grpc.UnaryServerInterceptor) *Gateway {
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(append(interceptors, server.AuthUnaryInterceptor)...))
+	pb.RegisterSimpleBankServer(grpcServer, server)
+
+	gateway, err := NewGateway(context.Background(), grpcServer)
+	require.NoError(t, err)
+	go gateway.Serve()
+	t.Cleanup(func() {
+		grpcServer.Stop()
+		gateway.Close()
+	})
+	return gateway
+}
+
+func TestGateway(t *testing.T) {
+	account := db.Account{
+		ID:       utils.RandomInt(1, 1000),
+		Owner:    utils.RandomOwner(),
+		Balance:  utils.RandomBalance(),
+		Currency: utils.USD,
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				accessToken, _, err := server.token.CreateToken(account.Owner, utils.CustomerRole, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), account.Owner)
+			},
+		},
+		{
+			// the auth interceptor rejects the call before the method runs
+			name:      "no authorization",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			gateway := newTestGateway(t, server)
+
+			request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+			tc.setupAuth(t, request, server)
+
+			recorder := httptest.NewRecorder()
+			gateway.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestGatewayClientIP checks the calls of the gateway are recognized, so the address of the HTTP client is used
+func TestGatewayClientIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	clientIP := make(chan string, 1)
+	gateway := newTestGateway(t, server, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		clientIP <- server.extractMetadata(ctx).ClientIP
+		return handler(ctx, req)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/accounts/1", nil)
+	request.RemoteAddr = "203.0.113.7:54321"
+
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, request)
+	require.Equal(t, "203.0.113.7", <-clientIP)
+}
@@ -1,32 +1,82 @@
 package gapi
 
 import (
+	"context"
 	"fmt"
+	"github.com/micaelapucciariello/simplebank/activity"
+	"github.com/micaelapucciariello/simplebank/apikey"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/oauth"
 	"github.com/micaelapucciariello/simplebank/pb"
 	"github.com/micaelapucciariello/simplebank/token"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/webhook"
+	"github.com/rs/zerolog/log"
 )
 
 // Server serves gRPC requests
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	store  db.Store
-	token  token.Maker
-	config utils.Config
+	store    db.Store
+	token    token.Maker
+	config   utils.Config
+	webhooks *webhook.Publisher
+	mailer   mail.Mailer
+	// passwordChanges rejects tokens issued before the user changed its password
+	passwordChanges *token.PasswordChangeCache
+	mfa             *mfa.Service
+	lockout         *lockout.Guard
+	apiKeys         *apikey.Authenticator
+	oauth           *oauth.Provider
+	activity        *activity.Broker
 }
 
 func NewServer(config utils.Config, store db.Store) (server *Server, err error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token validator: %w", err)
 	}
 
+	mailer, err := mail.NewMailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+
+	guard := lockout.NewGuard(store, config)
 	server = &Server{
-		store:  store,
-		token:  tokenMaker,
-		config: config,
+		store:           store,
+		token:           tokenMaker,
+		config:          config,
+		webhooks:        webhook.NewPublisher(store),
+		mailer:          mailer,
+		passwordChanges: token.NewPasswordChangeCache(config.PasswordChangeCacheTTL, store.GetUserPasswordChangedAt),
+		mfa:             mfa.NewService(store, guard, config.MFAIssuer, config.MFAChallengeDuration),
+		lockout:         guard,
+		apiKeys:         apikey.NewAuthenticator(store),
+		oauth:           oauth.NewProvider(store, tokenMaker, config),
+		activity:        activity.NewBroker(),
 	}
 
 	return
 }
+
+// CloseAccountActivity ends the WatchAccountActivity streams, GracefulStop would wait for them otherwise.
+// The clients resume on another instance from the last entry_id they got
+func (s *Server) CloseAccountActivity() {
+	s.activity.Close()
+}
+
+// ListenAccountActivity feeds the WatchAccountActivity streams from the notifications of TransferTx until ctx is done
+func (s *Server) ListenAccountActivity(ctx context.Context) error {
+	return s.activity.Listen(ctx, s.config.SourceName)
+}
+
+// publishEvent notifies the webhook subscribers of the owner, a failure doesn't fail the request
+func (s *Server) publishEvent(ctx context.Context, owner string, eventType string, data interface{}) {
+	if err := s.webhooks.Publish(ctx, owner, eventType, data); err != nil {
+		log.Error().Err(err).Str("event", eventType).Str("owner", owner).Msg("cannot publish webhook event")
+	}
+}
@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// publicMethods can be called without credentials, every other method is protected by default
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName:       true,
+	pb.SimpleBank_LoginUser_FullMethodName:        true,
+	pb.SimpleBank_VerifyLoginMfa_FullMethodName:   true,
+	pb.SimpleBank_VerifyEmail_FullMethodName:      true,
+	pb.SimpleBank_ForgotPassword_FullMethodName:   true,
+	pb.SimpleBank_ResetPassword_FullMethodName:    true,
+	pb.SimpleBank_RenewAccessToken_FullMethodName: true,
+	// the refresh token in the request authenticates the caller
+	pb.SimpleBank_LogoutUser_FullMethodName: true,
+
+	grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfo_FullMethodName: true,
+	// orchestrators probe the health service without credentials
+	grpc_health_v1.Health_Check_FullMethodName: true,
+	grpc_health_v1.Health_Watch_FullMethodName: true,
+}
+
+// AuthUnaryInterceptor authenticates the caller of protected methods and puts its payload on the context
+func (s *Server) AuthUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticateMethod(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor is AuthUnaryInterceptor for streaming methods
+func (s *Server) AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticateMethod(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (s *Server) authenticateMethod(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	payload, err := s.authenticate(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, payloadKey{}, payload), nil
+}
+
+// authenticatedStream replaces the context of the stream with the one carrying the payload
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,214 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	server, err := NewServer(utils.Config{
+		TokenSymmetricKey: utils.RandomString(32),
+		TokenDuration:     time.Minute,
+		MailerDriver:      "file",
+		MailOutputDir:     t.TempDir(),
+	}, store)
+	require.NoError(t, err)
+
+	// tokens are checked against password changes, tests that care set their own expectation first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+
+	return server
+}
+
+func contextWithAuthorization(authorization string) context.Context {
+	md := metadata.MD{authorizationHeader: []string{authorization}}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestAuthUnaryInterceptor(t *testing.T) {
+	username := utils.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		method        string
+		setupAuth     func(t *testing.T, maker token.Maker) context.Context
+		checkResponse func(t *testing.T, payload *token.Payload, err error)
+	}{
+		{
+			name:   "public method without credentials",
+			method: pb.SimpleBank_LoginUser_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.NoError(t, err)
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name:   "health check without credentials",
+			method: grpc_health_v1.Health_Check_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.NoError(t, err)
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name:   "protected method without credentials",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:   "protected method with access token",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				accessToken, _, err := maker.CreateToken(username, utils.CustomerRole, time.Minute)
+				require.NoError(t, err)
+				return contextWithAuthorization(fmt.Sprintf("Bearer %s", accessToken))
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.NoError(t, err)
+				require.Equal(t, username, payload.UserName)
+			},
+		},
+		{
+			name:   "refresh token",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				refreshToken, _, err := maker.CreateRefreshToken(username, utils.CustomerRole, time.Minute)
+				require.NoError(t, err)
+				return contextWithAuthorization(fmt.Sprintf("Bearer %s", refreshToken))
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:   "expired access token",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				accessToken, _, err := maker.CreateToken(username, utils.CustomerRole, -time.Minute)
+				require.NoError(t, err)
+				return contextWithAuthorization(fmt.Sprintf("Bearer %s", accessToken))
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:   "unsupported authorization type",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				return contextWithAuthorization("Basic dXNlcjpwYXNz")
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:   "oauth token on a method of users only",
+			method: pb.SimpleBank_ListSessions_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				accessToken, _, err := maker.CreateScopedToken(username, "client", []string{"webhooks:read"}, time.Minute)
+				require.NoError(t, err)
+				return contextWithAuthorization(fmt.Sprintf("Bearer %s", accessToken))
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:   "api key on a method of users only",
+			method: pb.SimpleBank_EnrollTotp_FullMethodName,
+			setupAuth: func(t *testing.T, maker token.Maker) context.Context {
+				return contextWithAuthorization("ApiKey sbk_prefix_secret")
+			},
+			checkResponse: func(t *testing.T, payload *token.Payload, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			var payload *token.Payload
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				payload, _ = ctx.Value(payloadKey{}).(*token.Payload)
+				return nil, nil
+			}
+
+			ctx := tc.setupAuth(t, server.token)
+			_, err := server.AuthUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			tc.checkResponse(t, payload, err)
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthStreamInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	info := &grpc.StreamServerInfo{FullMethod: "/pb.SimpleBank/WatchSomething"}
+
+	var payload *token.Payload
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		payload, _ = stream.Context().Value(payloadKey{}).(*token.Payload)
+		return nil
+	}
+
+	// new methods are protected by default
+	err := server.AuthStreamInterceptor(nil, &testServerStream{ctx: context.Background()}, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	username := utils.RandomOwner()
+	accessToken, _, err := server.token.CreateToken(username, utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	ctx := contextWithAuthorization(fmt.Sprintf("Bearer %s", accessToken))
+	err = server.AuthStreamInterceptor(nil, &testServerStream{ctx: ctx}, info, handler)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.UserName)
+}
@@ -0,0 +1,59 @@
+package gapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+)
+
+// ServeJWKS publishes the token verification keys as a JWK set so other services can validate access tokens offline
+func (s *Server) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	s.serveKeys(w, r, func(keys *token.KeyRing) interface{} {
+		return keys.JWKS()
+	})
+}
+
+// ServePasetoKeys publishes the token verification keys in the PASERK k4.public format
+func (s *Server) ServePasetoKeys(w http.ResponseWriter, r *http.Request) {
+	s.serveKeys(w, r, func(keys *token.KeyRing) interface{} {
+		return keys.PaserkKeys()
+	})
+}
+
+func (s *Server) serveKeys(w http.ResponseWriter, r *http.Request, document func(keys *token.KeyRing) interface{}) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// symmetric tokens can't be verified by anyone else
+	maker, ok := s.token.(token.PublicMaker)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := json.Marshal(document(maker.KeyRing()))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.config.TokenKeysMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(body)
+}
@@ -0,0 +1,106 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var requestIDMetadata = strings.ToLower(logging.RequestIDHeader)
+
+// GrpcLogger logs every unary call, it has to run before the auth interceptor to record the user
+func GrpcLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, request := newGrpcRequest(ctx)
+
+	rsp, err := handler(ctx, req)
+	logGrpcCall(request, info.FullMethod, err, time.Since(start))
+	return rsp, err
+}
+
+// GrpcStreamLogger is GrpcLogger for streaming calls, they are logged when the stream ends
+func GrpcStreamLogger(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, request := newGrpcRequest(ss.Context())
+
+	err := handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	logGrpcCall(request, info.FullMethod, err, time.Since(start))
+	return err
+}
+
+// newGrpcRequest keeps the request id the client sent in the metadata and sends it back in the header
+func newGrpcRequest(ctx context.Context) (context.Context, *logging.Request) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+
+	ctx, request := logging.NewRequest(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, request.ID))
+	return ctx, request
+}
+
+func logGrpcCall(request *logging.Request, method string, err error, duration time.Duration) {
+	statusCode := status.Code(err)
+
+	var event *zerolog.Event
+	switch statusCode {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		event = log.Error().Err(err)
+	default:
+		event = log.Info()
+	}
+
+	event.Str("protocol", "grpc").
+		Str("method", method).
+		Int("status_code", int(statusCode)).
+		Str("status_text", statusCode.String()).
+		Dur("duration", duration).
+		Str("request_id", request.ID).
+		Str("user", request.User()).
+		Msg("received a gRPC request")
+}
+
+// HttpLogger logs every request of the gateway with its HTTP status, GrpcLogger logs the gRPC call it makes
+// under the same request id
+func HttpLogger(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, request := logging.NewRequest(r.Context(), r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, request.ID)
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, r.WithContext(ctx))
+
+		logging.HTTPRequest(request, r.Method, r.URL.Path, recorder.statusCode, time.Since(start))
+	})
+}
+
+// responseRecorder keeps the status code written to the response
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// gatewayRequestID is a runtime.WithMetadata annotator passing the request id of the HTTP request to the gRPC call
+func gatewayRequestID(ctx context.Context, r *http.Request) metadata.MD {
+	if request := logging.FromContext(ctx); request != nil {
+		return metadata.Pairs(requestIDMetadata, request.ID)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrpcLogger(t *testing.T) {
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	username := utils.RandomOwner()
+	accessToken, _, err := server.token.CreateToken(username, utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	md := metadata.Pairs(authorizationHeader, fmt.Sprintf("Bearer %s", accessToken), requestIDMetadata, "client-request")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListSessions_FullMethodName}
+
+	// the logger wraps the auth interceptor like in the server chain
+	_, err = GrpcLogger(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.AuthUnaryInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Errorf(codes.NotFound, "not found")
+		})
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	require.Equal(t, "info", fields["level"])
+	require.Equal(t, "grpc", fields["protocol"])
+	require.Equal(t, pb.SimpleBank_ListSessions_FullMethodName, fields["method"])
+	require.Equal(t, codes.NotFound.String(), fields["status_text"])
+	require.Equal(t, "client-request", fields["request_id"])
+	require.Equal(t, username, fields["user"])
+}
+
+func TestHttpLoggerThroughGateway(t *testing.T) {
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	handler := HttpLogger(newTestGateway(t, server, GrpcLogger))
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/accounts/12", nil)
+	request.Header.Set(logging.RequestIDHeader, "client-request")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// the gRPC call is logged first, then the HTTP request, both with the id the client sent
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for i, protocol := range []string{"grpc", "http"} {
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &fields))
+		require.Equal(t, protocol, fields["protocol"])
+		require.Equal(t, "client-request", fields["request_id"])
+	}
+}
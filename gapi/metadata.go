@@ -4,6 +4,8 @@ import (
 	"context"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"net"
+	"strings"
 )
 
 const (
@@ -20,21 +22,48 @@ type Metadata struct {
 func (s *Server) extractMetadata(ctx context.Context) *Metadata {
 	md := &Metadata{}
 
+	fromGateway := false
+	if p, ok := peer.FromContext(ctx); ok {
+		md.ClientIP = p.Addr.String()
+		// the port changes on every connection, only the host identifies the client
+		if host, _, err := net.SplitHostPort(md.ClientIP); err == nil {
+			md.ClientIP = host
+		}
+		// no remote client can have the address of the in-process connections of the gateway
+		_, fromGateway = p.Addr.(gatewayAddr)
+	}
+
 	if m, ok := metadata.FromIncomingContext(ctx); ok {
-		if ua := m.Get(grpcGatewayUserAgentHeader)[0]; len(ua) > 0 {
-			md.UserAgent = m.Get(grpcGatewayUserAgentHeader)[0]
+		if ua := firstValue(m, grpcGatewayUserAgentHeader); len(ua) > 0 {
+			md.UserAgent = ua
 		}
-		if ua := m.Get(userAgentHeader)[0]; len(ua) > 0 {
-			md.UserAgent = m.Get(userAgentHeader)[0]
+		if ua := firstValue(m, userAgentHeader); len(ua) > 0 {
+			md.UserAgent = ua
 		}
-		if cip := m.Get(xForwardedForHeader)[0]; len(cip) > 0 {
-			md.UserAgent = m.Get(xForwardedForHeader)[0]
+		// calls from the gateway carry the HTTP client address, the peer is the gateway itself. The gateway appends
+		// the address of the connection to the header sent by the client, only that last entry can be trusted,
+		// and the header of any other caller is ignored
+		if fromGateway {
+			if cip := lastValue(m, xForwardedForHeader); len(cip) > 0 {
+				forwarded := strings.Split(cip, ",")
+				md.ClientIP = strings.TrimSpace(forwarded[len(forwarded)-1])
+			}
 		}
 	}
 
-	if ip, ok := peer.FromContext(ctx); ok {
-		md.ClientIP = ip.Addr.String()
+	return md
+}
+
+func firstValue(m metadata.MD, key string) string {
+	if values := m.Get(key); len(values) > 0 {
+		return values[0]
 	}
+	return ""
+}
 
-	return md
+func lastValue(m metadata.MD, key string) string {
+	if values := m.Get(key); len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
 }
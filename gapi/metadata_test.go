@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestExtractMetadataClientIP(t *testing.T) {
+	testCases := []struct {
+		name       string
+		addr       net.Addr
+		forwarded  []string
+		expectedIP string
+	}{
+		{
+			name:       "direct call",
+			addr:       &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 54321},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			// only the gateway can set the address of the client
+			name:       "direct call with forwarded header",
+			addr:       &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 54321},
+			forwarded:  []string{"10.0.0.1"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			// a peer can't pick the address type of the gateway
+			name:       "network named like the gateway",
+			addr:       &net.UnixAddr{Name: "gateway", Net: "gateway"},
+			forwarded:  []string{"10.0.0.1"},
+			expectedIP: "gateway",
+		},
+		{
+			name:       "gateway call",
+			addr:       gatewayAddr{},
+			forwarded:  []string{"203.0.113.7"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			// the gateway appends the address of the connection to the header sent by the client
+			name:       "gateway call with forwarded header",
+			addr:       gatewayAddr{},
+			forwarded:  []string{"10.0.0.1, 203.0.113.7"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:       "gateway call with forwarded metadata",
+			addr:       gatewayAddr{},
+			forwarded:  []string{"10.0.0.1", "203.0.113.7"},
+			expectedIP: "203.0.113.7",
+		},
+	}
+
+	server := &Server{}
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tc.addr})
+			md := metadata.MD{}
+			for _, value := range tc.forwarded {
+				md.Append(xForwardedForHeader, value)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			require.Equal(t, tc.expectedIP, server.extractMetadata(ctx).ClientIP)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"time"
+)
+
+// gatewayServer labels the HTTP metrics of the gateway
+const gatewayServer = "gateway"
+
+// GrpcMetrics records the count and duration of every unary call
+func GrpcMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	rsp, err := handler(ctx, req)
+	metrics.ObserveGrpcRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+	return rsp, err
+}
+
+// GrpcStreamMetrics is GrpcMetrics for streaming calls, they are recorded when the stream ends
+func GrpcStreamMetrics(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	metrics.ObserveGrpcRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+	return err
+}
+
+// HttpMetrics records the count and duration of every request of the gateway by the route it matched,
+// the mux has to be created with GatewayRoute for the route to be known
+func HttpMetrics(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, route := metrics.NewRoute(r.Context())
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, r.WithContext(ctx))
+
+		metrics.ObserveHTTPRequest(gatewayServer, r.Method, route.Pattern(), recorder.statusCode, time.Since(start))
+	})
+}
+
+// GatewayRoute is a runtime.WithMetadata annotator passing the pattern the gateway matched to HttpMetrics,
+// it adds no metadata
+func GatewayRoute(ctx context.Context, r *http.Request) metadata.MD {
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		metrics.SetRoute(ctx, pattern)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	"github.com/micaelapucciariello/simplebank/metrics"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHttpMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	handler := HttpMetrics(newTestGateway(t, server, GrpcMetrics))
+
+	matched := `simplebank_http_requests_total{code="401",method="GET",route="/v1/accounts/{id}",server="gateway"}`
+	unmatched := `simplebank_http_requests_total{code="404",method="GET",route="unmatched",server="gateway"}`
+	// the gateway calls the gRPC server, so they're also recorded as gRPC requests
+	grpcCall := `simplebank_grpc_requests_total{code="Unauthenticated",method="/pb.SimpleBank/GetAccount"}`
+	matchedBefore, unmatchedBefore, grpcCallBefore := scrapeMetric(t, matched), scrapeMetric(t, unmatched), scrapeMetric(t, grpcCall)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/accounts/12", nil))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/unknown", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// the route pattern is recorded instead of the path
+	require.Equal(t, matchedBefore+1, scrapeMetric(t, matched))
+	require.Equal(t, unmatchedBefore+1, scrapeMetric(t, unmatched))
+	require.Equal(t, grpcCallBefore+1, scrapeMetric(t, grpcCall))
+}
+
+func TestGrpcMetrics(t *testing.T) {
+	series := `simplebank_grpc_requests_total{code="NotFound",method="/pb.SimpleBank/GetAccount"}`
+	before := scrapeMetric(t, series)
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_GetAccount_FullMethodName}
+	_, err := GrpcMetrics(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Errorf(codes.NotFound, "account not found")
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.Equal(t, before+1, scrapeMetric(t, series))
+}
+
+// scrapeMetric returns the value of the series served by the metrics handler, 0 if it wasn't recorded yet
+func scrapeMetric(t *testing.T, series string) float64 {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			f, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return f
+		}
+	}
+	return 0
+}
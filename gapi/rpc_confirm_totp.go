@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ConfirmTotp(ctx context.Context, req *pb.ConfirmTotpRequest) (*pb.ConfirmTotpResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateConfirmTotpReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	recoveryCodes, err := s.mfa.Confirm(ctx, authPayload.UserName, req.GetCode())
+	if err != nil {
+		switch err {
+		case mfa.ErrNotEnrolled:
+			return nil, status.Errorf(codes.NotFound, "%s", err)
+		case mfa.ErrAlreadyEnabled:
+			return nil, status.Errorf(codes.AlreadyExists, "%s", err)
+		case mfa.ErrInvalidCode:
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error confirming totp: %s", err)
+	}
+
+	rsp := &pb.ConfirmTotpResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+	return rsp, nil
+}
+
+func validateConfirmTotpReq(req *pb.ConfirmTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateTotpCode(req.Code); err != nil {
+		violations = append(violations, ViolationErr("code", err.Error()))
+	}
+
+	return violations
+}
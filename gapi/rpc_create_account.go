@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"github.com/lib/pq"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"github.com/micaelapucciariello/simplebank/webhook"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAccount opens an account of the caller, a user has one account per currency
+func (s *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateAccountReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	account, err := s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    authPayload.UserName,
+		Balance:  0,
+		Currency: req.GetCurrency(),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Errorf(codes.AlreadyExists, "account already exists: %s", err)
+			case "foreign_key_violation":
+				return nil, status.Errorf(codes.FailedPrecondition, "owner doesn't exist: %s", err)
+			}
+		}
+		return nil, status.Errorf(codes.Internal, "db err while creating account: %s", err)
+	}
+
+	s.publishEvent(ctx, account.Owner, webhook.EventAccountCreated, account)
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func validateCreateAccountReq(req *pb.CreateAccountRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, ViolationErr("currency", err.Error()))
+	}
+
+	return violations
+}
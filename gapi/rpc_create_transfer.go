@@ -0,0 +1,163 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+var (
+	errEmailNotVerified    = errors.New("email must be verified before making transfers")
+	errTransferMfaRequired = errors.New("two-factor authentication must be enabled for transfers above the threshold")
+)
+
+// CreateTransfer moves money from an account of the caller to any account with the same currency
+func (s *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateTransferReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	user, err := s.store.GetUser(ctx, authPayload.UserName)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+	}
+	if !user.IsEmailVerified {
+		return nil, FailedPreconditionError(ReasonEmailNotVerified, errEmailNotVerified, nil,
+			PreconditionViolation("EMAIL", "users/"+user.Username, "email is not verified"))
+	}
+
+	fromAccount, err := s.authorizedAccount(ctx, authPayload, policy.TransferFromAccount, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if err = validTransferAccount(fromAccount, req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	toAccount, err := s.getAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if err = validTransferAccount(toAccount, req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	// TransferTx checks the balance again when it debits the account, this only skips the transaction
+	if fromAccount.Balance < req.GetAmount() {
+		return nil, insufficientFundsError(fromAccount.ID,
+			fmt.Sprintf("balance %v is lower than the amount %v", fromAccount.Balance, req.GetAmount()))
+	}
+
+	// the code is only accepted once, it's checked last so a transfer failing the other checks doesn't use it up
+	if err = s.verifyTransferCode(ctx, user.Username, req.GetAmount(), req.GetTotpCode()); err != nil {
+		return nil, err
+	}
+
+	result, err := s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		AfterTransfer: s.webhooks.TransferEvents(ctx),
+	})
+	if err != nil {
+		if err == db.ErrInsufficientFunds {
+			return nil, insufficientFundsError(fromAccount.ID,
+				fmt.Sprintf("balance is lower than the amount %v", req.GetAmount()))
+		}
+		return nil, status.Errorf(codes.Internal, "db err while creating transfer: %s", err)
+	}
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccountID),
+	}, nil
+}
+
+func validateCreateTransferReq(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, ViolationErr("from_account_id", err.Error()))
+	}
+
+	if err := validator.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, ViolationErr("to_account_id", err.Error()))
+	} else if req.GetToAccountId() == req.GetFromAccountId() {
+		violations = append(violations, ViolationErr("to_account_id", "must be different from from_account_id"))
+	}
+
+	if req.GetAmount() < 1 {
+		violations = append(violations, ViolationErr("amount", "must be greater than 0"))
+	}
+
+	if err := validator.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, ViolationErr("currency", err.Error()))
+	}
+
+	return violations
+}
+
+// verifyTransferCode asks for a TOTP code when the amount is above the MFA transfer threshold
+func (s *Server) verifyTransferCode(ctx context.Context, username string, amount int64, code string) error {
+	if s.config.MFATransferThreshold <= 0 || amount <= s.config.MFATransferThreshold {
+		return nil
+	}
+
+	err := s.mfa.VerifyCode(ctx, username, code)
+	if err != nil {
+		if locked, ok := err.(*mfa.LockedError); ok {
+			return LockedError(err, locked.RetryAfter)
+		}
+		switch err {
+		case mfa.ErrNotEnrolled:
+			return FailedPreconditionError(ReasonTransferMfaRequired, errTransferMfaRequired, nil,
+				PreconditionViolation("MFA", "users/"+username, "two-factor authentication is not enabled"))
+		case mfa.ErrInvalidCode:
+			return status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return status.Errorf(codes.Internal, "error verifying mfa code: %s", err)
+	}
+
+	return nil
+}
+
+// insufficientFundsError is returned when the balance of the from account doesn't cover the amount
+func insufficientFundsError(accountID int64, description string) error {
+	return FailedPreconditionError(ReasonInsufficientFunds,
+		fmt.Errorf("account [%v] has insufficient funds", accountID),
+		map[string]string{"account_id": strconv.FormatInt(accountID, 10)},
+		PreconditionViolation("BALANCE", accountSubject(accountID), description))
+}
+
+// validTransferAccount checks the account can take part in a transfer in the currency
+func validTransferAccount(account db.Account, currency string) error {
+	metadata := map[string]string{"account_id": strconv.FormatInt(account.ID, 10)}
+
+	if account.Currency != currency {
+		return FailedPreconditionError(ReasonCurrencyMismatch,
+			fmt.Errorf("account [%v] currency mismatched: account currency %v - transfer currency %v", account.ID, account.Currency, currency),
+			metadata,
+			PreconditionViolation("CURRENCY", accountSubject(account.ID), fmt.Sprintf("account currency is %v", account.Currency)))
+	}
+
+	if account.IsFrozen {
+		return FailedPreconditionError(ReasonAccountFrozen,
+			fmt.Errorf("account [%v] is frozen", account.ID),
+			metadata,
+			PreconditionViolation("FROZEN", accountSubject(account.ID), "account is frozen"))
+	}
+
+	return nil
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"github.com/lib/pq"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mail"
 	"github.com/micaelapucciariello/simplebank/pb"
 	"github.com/micaelapucciariello/simplebank/utils"
 	"github.com/micaelapucciariello/simplebank/validator"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
 func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
@@ -23,27 +26,42 @@ func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb
 		return nil, status.Errorf(codes.Internal, "error hashing password: %s", err)
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.GetUsername(),
-		HashedPassword: hashedPassword,
-		FullName:       req.GetFullName(),
-		Email:          req.GetEmail(),
+	secretCode, err := utils.RandomSecret(32)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error generating verification code: %s", err)
+	}
+
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.GetUsername(),
+			HashedPassword: hashedPassword,
+			FullName:       req.GetFullName(),
+			Email:          req.GetEmail(),
+		},
+		VerifyEmailSecretCodeHash: utils.HashSecret(secretCode),
+		VerifyEmailExpiredAt:      time.Now().Add(s.config.VerifyEmailDuration),
 	}
 
-	user, err := s.store.CreateUser(ctx, arg)
+	result, err := s.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
-				return nil, status.Errorf(codes.Internal, "username already exists: %s", err)
+				return nil, status.Errorf(codes.AlreadyExists, "username already exists: %s", err)
 			}
 
 		}
 		return nil, status.Errorf(codes.Internal, "db err while creating user: %s", err)
 	}
 
+	// the code is mailed once the user is committed, if the mail fails the user asks for another with ResendVerifyEmail
+	subject, content := mail.NewVerifyEmail(s.config.VerifyEmailURL, result.User.FullName, result.VerifyEmail.ID, secretCode)
+	if err = s.mailer.SendEmail(ctx, subject, content, []string{result.User.Email}); err != nil {
+		log.Error().Err(err).Str("user", result.User.Username).Msg("cannot send verify email")
+	}
+
 	rsp := &pb.CreateUserResponse{
-		User: convertUser(user),
+		User: convertUser(result.User),
 	}
 	return rsp, nil
 }
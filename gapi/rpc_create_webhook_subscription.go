@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"github.com/micaelapucciariello/simplebank/webhook"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) CreateWebhookSubscription(ctx context.Context, req *pb.CreateWebhookSubscriptionRequest) (*pb.CreateWebhookSubscriptionResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateWebhookSubscriptionReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	secret := req.GetSecret()
+	if secret == "" {
+		secret, err = webhook.NewSecret()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error generating webhook secret: %s", err)
+		}
+	}
+
+	subscription, err := s.store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:      authPayload.UserName,
+		Url:        req.GetUrl(),
+		EventTypes: req.GetEventTypes(),
+		Secret:     secret,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, status.Errorf(codes.FailedPrecondition, "user doesn't exist: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "db err while creating webhook subscription: %s", err)
+	}
+
+	rsp := &pb.CreateWebhookSubscriptionResponse{
+		Subscription: convertWebhookSubscription(subscription),
+		Secret:       subscription.Secret,
+	}
+	return rsp, nil
+}
+
+func validateCreateWebhookSubscriptionReq(req *pb.CreateWebhookSubscriptionRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := webhook.ValidateURL(req.Url); err != nil {
+		violations = append(violations, ViolationErr("url", err.Error()))
+	}
+	if len(req.EventTypes) == 0 {
+		violations = append(violations, ViolationErr("event_types", "at least one event type is required"))
+	}
+	for _, eventType := range req.EventTypes {
+		if !webhook.IsSupportedEvent(eventType) {
+			violations = append(violations, ViolationErr("event_types", fmt.Sprintf("unsupported event type: %s", eventType)))
+		}
+	}
+	if req.Secret != "" {
+		if err := validator.ValidateLength(req.Secret, 16, 128); err != nil {
+			violations = append(violations, ViolationErr("secret", err.Error()))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,32 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validator.ValidateID(req.GetId()); err != nil {
+		return nil, InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{ViolationErr("id", err.Error())})
+	}
+
+	if _, err = s.authorizedAccount(ctx, authPayload, policy.DeleteAccount, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err = s.store.DeleteAccount(ctx, req.GetId()); err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while deleting account: %s", err)
+	}
+
+	return &pb.DeleteAccountResponse{}, nil
+}
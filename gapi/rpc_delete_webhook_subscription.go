@@ -0,0 +1,51 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) DeleteWebhookSubscription(ctx context.Context, req *pb.DeleteWebhookSubscriptionRequest) (*pb.DeleteWebhookSubscriptionResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() < 1 {
+		return nil, InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{ViolationErr("id", "must be greater than 0")})
+	}
+
+	if _, err = s.ownedWebhookSubscription(ctx, authPayload, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	err = s.store.DeleteWebhookSubscription(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while deleting webhook subscription: %s", err)
+	}
+
+	return &pb.DeleteWebhookSubscriptionResponse{}, nil
+}
+
+// ownedWebhookSubscription fetches a subscription and checks it belongs to the caller
+func (s *Server) ownedWebhookSubscription(ctx context.Context, authPayload *token.Payload, id int64) (db.WebhookSubscription, error) {
+	subscription, err := s.store.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return subscription, status.Errorf(codes.NotFound, "webhook subscription not found: %s", err)
+		}
+		return subscription, status.Errorf(codes.Internal, "error getting webhook subscription: %s", err)
+	}
+
+	if subscription.Owner != authPayload.UserName {
+		return subscription, status.Errorf(codes.PermissionDenied, "webhook subscription doesn't belong to the authenticated user")
+	}
+
+	return subscription, nil
+}
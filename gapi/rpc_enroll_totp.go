@@ -0,0 +1,30 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) EnrollTotp(ctx context.Context, req *pb.EnrollTotpRequest) (*pb.EnrollTotpResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, uri, err := s.mfa.Enroll(ctx, authPayload.UserName)
+	if err != nil {
+		if err == mfa.ErrAlreadyEnabled {
+			return nil, status.Errorf(codes.AlreadyExists, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error enrolling totp: %s", err)
+	}
+
+	rsp := &pb.EnrollTotpResponse{
+		Secret:     secret,
+		OtpauthUri: uri,
+	}
+	return rsp, nil
+}
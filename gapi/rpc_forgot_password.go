@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// forgotPasswordMessage is the only answer to a reset request, so the RPC doesn't reveal which emails are registered
+const forgotPasswordMessage = "if the email is registered you will receive a link to reset your password"
+
+func (s *Server) ForgotPassword(ctx context.Context, req *pb.ForgotPasswordRequest) (*pb.ForgotPasswordResponse, error) {
+	if violations := validateForgotPasswordReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	rsp := &pb.ForgotPasswordResponse{
+		Message: forgotPasswordMessage,
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return rsp, nil
+		}
+		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+	}
+
+	token, err := utils.RandomSecret(32)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error generating reset token: %s", err)
+	}
+
+	_, err = s.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: utils.HashSecret(token),
+		ExpiredAt: time.Now().Add(s.config.ResetPasswordDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while creating reset token: %s", err)
+	}
+
+	// the email is sent in background so response times don't depend on the email being registered
+	subject, content := mail.NewResetPasswordEmail(s.config.ResetPasswordURL, user.FullName, token)
+	mail.SendInBackground(s.mailer, subject, content, []string{user.Email})
+
+	return rsp, nil
+}
+
+func validateForgotPasswordReq(req *pb.ForgotPasswordRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateEmail(req.Email); err != nil {
+		violations = append(violations, ViolationErr("email", err.Error()))
+	}
+
+	return violations
+}
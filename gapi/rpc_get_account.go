@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+func (s *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validator.ValidateID(req.GetId()); err != nil {
+		return nil, InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{ViolationErr("id", err.Error())})
+	}
+
+	account, err := s.authorizedAccount(ctx, authPayload, policy.ViewAccount, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+// authorizedAccount fetches an account and checks the policy allows the caller to perform the action on it
+func (s *Server) authorizedAccount(ctx context.Context, authPayload *token.Payload, action policy.Action, id int64) (db.Account, error) {
+	account, err := s.getAccount(ctx, id)
+	if err != nil {
+		return account, err
+	}
+
+	if err = policy.Authorize(authPayload, action, account.Owner); err != nil {
+		return account, DomainError(codes.PermissionDenied, ReasonAccountNotOwned,
+			fmt.Errorf("account [%v] doesn't belong to the authenticated user", id),
+			map[string]string{"account_id": strconv.FormatInt(id, 10)})
+	}
+
+	return account, nil
+}
+
+func (s *Server) getAccount(ctx context.Context, id int64) (db.Account, error) {
+	account, err := s.store.GetAccount(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, DomainError(codes.NotFound, ReasonAccountNotFound,
+				fmt.Errorf("account [%v] not found", id),
+				map[string]string{"account_id": strconv.FormatInt(id, 10)})
+		}
+		return account, status.Errorf(codes.Internal, "error getting account: %s", err)
+	}
+
+	return account, nil
+}
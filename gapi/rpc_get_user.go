@@ -0,0 +1,38 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetUser returns the caller, admins can get every user
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validator.ValidateUsername(req.GetUsername()); err != nil {
+		return nil, InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{ViolationErr("username", err.Error())})
+	}
+
+	if err = policy.Authorize(authPayload, policy.ViewUser, req.GetUsername()); err != nil {
+		return nil, PermissionDeniedError(err)
+	}
+
+	user, err := s.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+	}
+
+	return &pb.GetUserResponse{User: convertUser(user)}, nil
+}
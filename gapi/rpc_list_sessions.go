@@ -0,0 +1,27 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListSessions returns the sessions of the user that can still renew tokens
+func (s *Server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.store.ListActiveSessions(ctx, authPayload.UserName)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while listing sessions: %s", err)
+	}
+
+	rsp := &pb.ListSessionsResponse{}
+	for _, session := range sessions {
+		rsp.Sessions = append(rsp.Sessions, convertSession(session))
+	}
+	return rsp, nil
+}
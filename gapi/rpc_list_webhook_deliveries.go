@@ -0,0 +1,43 @@
+package gapi
+
+import (
+	"context"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := validatePagination(req.GetPageId(), req.GetPageSize())
+	if req.GetSubscriptionId() < 1 {
+		violations = append(violations, ViolationErr("subscription_id", "must be greater than 0"))
+	}
+	if violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	if _, err = s.ownedWebhookSubscription(ctx, authPayload, req.GetSubscriptionId()); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: req.GetSubscriptionId(),
+		Limit:          req.GetPageSize(),
+		Offset:         (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while listing webhook deliveries: %s", err)
+	}
+
+	rsp := &pb.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		rsp.Deliveries = append(rsp.Deliveries, convertWebhookDelivery(delivery))
+	}
+	return rsp, nil
+}
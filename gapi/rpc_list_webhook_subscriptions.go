@@ -0,0 +1,47 @@
+package gapi
+
+import (
+	"context"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ListWebhookSubscriptions(ctx context.Context, req *pb.ListWebhookSubscriptionsRequest) (*pb.ListWebhookSubscriptionsResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validatePagination(req.GetPageId(), req.GetPageSize()); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	subscriptions, err := s.store.ListWebhookSubscriptions(ctx, db.ListWebhookSubscriptionsParams{
+		Owner:  authPayload.UserName,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while listing webhook subscriptions: %s", err)
+	}
+
+	rsp := &pb.ListWebhookSubscriptionsResponse{}
+	for _, subscription := range subscriptions {
+		rsp.Subscriptions = append(rsp.Subscriptions, convertWebhookSubscription(subscription))
+	}
+	return rsp, nil
+}
+
+func validatePagination(pageID, pageSize int32) (violations []*errdetails.BadRequest_FieldViolation) {
+	if pageID < 1 {
+		violations = append(violations, ViolationErr("page_id", "must be greater than 0"))
+	}
+	if pageSize < 5 || pageSize > 10 {
+		violations = append(violations, ViolationErr("page_size", "must be between 5 and 10"))
+	}
+
+	return violations
+}
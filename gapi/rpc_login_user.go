@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/metrics"
 	"github.com/micaelapucciariello/simplebank/pb"
 	"github.com/micaelapucciariello/simplebank/utils"
 	"github.com/micaelapucciariello/simplebank/validator"
@@ -18,25 +20,80 @@ func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.L
 		return nil, InvalidArgumentError(violations)
 	}
 
+	mtdt := s.extractMetadata(ctx)
+	retryAfter, err := s.lockout.Attempt(ctx, req.GetUsername(), mtdt.ClientIP)
+	if err != nil {
+		if err == lockout.ErrLocked {
+			return nil, LockedError(err, retryAfter)
+		}
+		return nil, status.Errorf(codes.Internal, "error recording login attempt: %s", err)
+	}
+
 	user, err := s.store.GetUser(ctx, req.GetUsername())
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "error invalid user: %s", err)
+			utils.CheckDummyPassword(req.GetPassword())
+			return nil, s.loginFailed(ctx, req.GetUsername())
 		}
 		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
 	}
 
 	err = utils.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "unauthorized user: %s", err)
+		return nil, s.loginFailed(ctx, req.GetUsername())
+	}
+
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error checking two-factor authentication: %s", err)
+	}
+
+	// the wrong codes count as failures of the user too, they're only cleared once the code is verified so logging
+	// in again doesn't reset them
+	unlockUsername := user.Username
+	if mfaEnabled {
+		unlockUsername = ""
+	}
+	if err = s.lockout.RecordSuccess(ctx, unlockUsername, mtdt.ClientIP); err != nil {
+		return nil, status.Errorf(codes.Internal, "error clearing login failures: %s", err)
+	}
+	metrics.LoginSucceeded()
+
+	// with 2FA enabled no token is issued until the code is verified with VerifyLoginMfa
+	if mfaEnabled {
+		mfaToken, expiresAt, err := s.mfa.CreateChallenge(ctx, user.Username)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error creating mfa challenge: %s", err)
+		}
+
+		rsp := &pb.LoginUserResponse{
+			MfaRequired:       true,
+			MfaToken:          mfaToken,
+			MfaTokenExpiresAt: timestamppb.New(expiresAt),
+		}
+		return rsp, nil
+	}
+
+	return s.createLoginSession(ctx, user)
+}
+
+// loginFailed delays the next attempt and returns the same error whether the user exists or not
+func (s *Server) loginFailed(ctx context.Context, username string) error {
+	metrics.LoginFailed()
+	if err := s.lockout.RecordFailure(ctx, username); err != nil {
+		return status.Errorf(codes.Internal, "error recording login failure: %s", err)
 	}
+	return status.Errorf(codes.Unauthenticated, "invalid username or password")
+}
 
-	accessToken, accessPayload, err := s.token.CreateToken(req.Username, s.config.TokenDuration)
+// createLoginSession issues the access and refresh tokens once every login factor was checked
+func (s *Server) createLoginSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	accessToken, accessPayload, err := s.token.CreateToken(user.Username, user.Role, s.config.TokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "error creating  access token: %s", err)
 	}
 
-	refreshToken, refreshPayload, err := s.token.CreateToken(req.Username, s.config.RefreshTokenDuration)
+	refreshToken, refreshPayload, err := s.token.CreateRefreshToken(user.Username, user.Role, s.config.RefreshTokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "error creating refresh token: %s", err)
 	}
@@ -44,13 +101,17 @@ func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.L
 	mtdt := s.extractMetadata(ctx)
 	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
-		Username:     req.Username,
+		Username:     user.Username,
 		RefreshToken: refreshToken,
 		UserAgent:    mtdt.UserAgent,
 		ClientIp:     mtdt.ClientIP,
 		IsBlocked:    false,
-		ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt},
+		ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt, Valid: true},
+		FamilyID:     refreshPayload.ID,
 	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error creating session: %s", err)
+	}
 
 	rsp := &pb.LoginUserResponse{
 		SessionId:             session.ID.String(),
@@ -73,4 +134,4 @@ func validateLoginUserReq(req *pb.LoginUserRequest) (violations []*errdetails.Ba
 	}
 
 	return violations
-}
\ No newline at end of file
+}
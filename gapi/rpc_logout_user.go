@@ -0,0 +1,55 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogoutUser blocks the session of the refresh token
+func (s *Server) LogoutUser(ctx context.Context, req *pb.LogoutUserRequest) (*pb.LogoutUserResponse, error) {
+	payload, err := s.token.VerifyToken(req.GetRefreshToken())
+	if err == nil {
+		err = payload.CheckType(token.RefreshToken)
+	}
+	if err != nil {
+		return nil, UnauthenticatedError(err)
+	}
+
+	_, err = s.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       payload.ID,
+		Username: payload.UserName,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "db err while blocking session: %s", err)
+	}
+
+	rsp := &pb.LogoutUserResponse{
+		Message: "logged out",
+	}
+	return rsp, nil
+}
+
+// LogoutAllSessions blocks every session of the user, access tokens already issued stay valid until they expire
+func (s *Server) LogoutAllSessions(ctx context.Context, req *pb.LogoutAllSessionsRequest) (*pb.LogoutAllSessionsResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.store.BlockUserSessions(ctx, authPayload.UserName); err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while blocking sessions: %s", err)
+	}
+
+	rsp := &pb.LogoutAllSessionsResponse{
+		Message: "logged out from every session",
+	}
+	return rsp, nil
+}
@@ -0,0 +1,91 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// RenewAccessToken consumes the refresh token and issues a new access and refresh token pair in the same session family,
+// presenting a consumed refresh token again revokes the whole family
+func (s *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	payload, err := s.token.VerifyToken(req.GetRefreshToken())
+	if err == nil {
+		err = payload.CheckType(token.RefreshToken)
+	}
+	if err != nil {
+		return nil, UnauthenticatedError(err)
+	}
+
+	session, err := s.store.GetSession(ctx, payload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "db err while getting session: %s", err)
+	}
+
+	if session.IsBlocked {
+		return nil, status.Errorf(codes.Unauthenticated, "session is blocked")
+	}
+
+	if !session.ExpiresAt.Valid || time.Now().After(session.ExpiresAt.Time) {
+		return nil, status.Errorf(codes.Unauthenticated, "session has expired")
+	}
+
+	if session.Username != payload.UserName || session.RefreshToken != req.GetRefreshToken() {
+		return nil, status.Errorf(codes.Unauthenticated, "session doesn't belong to the token user")
+	}
+
+	// the role may have changed since the login, it's read again instead of copied from the refresh token
+	user, err := s.store.GetUser(ctx, session.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while getting user: %s", err)
+	}
+
+	accessToken, accessPayload, err := s.token.CreateToken(user.Username, user.Role, s.config.TokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error creating access token: %s", err)
+	}
+
+	refreshToken, refreshPayload, err := s.token.CreateRefreshToken(user.Username, user.Role, s.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error creating refresh token: %s", err)
+	}
+
+	mtdt := s.extractMetadata(ctx)
+	result, err := s.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     payload.UserName,
+			RefreshToken: refreshToken,
+			UserAgent:    mtdt.UserAgent,
+			ClientIp:     mtdt.ClientIP,
+			IsBlocked:    false,
+			ExpiresAt:    sql.NullTime{Time: refreshPayload.ExpiredAt, Valid: true},
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while rotating session: %s", err)
+	}
+
+	if result.Reused {
+		return nil, status.Errorf(codes.Unauthenticated, "refresh token was already used, every session of its family was revoked")
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		SessionId:             result.Session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ReplayWebhookDelivery(ctx context.Context, req *pb.ReplayWebhookDeliveryRequest) (*pb.ReplayWebhookDeliveryResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateReplayWebhookDeliveryReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	if _, err = s.ownedWebhookSubscription(ctx, authPayload, req.GetSubscriptionId()); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.store.GetWebhookDelivery(ctx, req.GetDeliveryId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "webhook delivery not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error getting webhook delivery: %s", err)
+	}
+	if delivery.SubscriptionID != req.GetSubscriptionId() {
+		return nil, status.Errorf(codes.NotFound, "webhook delivery not found")
+	}
+
+	delivery, err = s.store.ReplayWebhookDelivery(ctx, req.GetDeliveryId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while replaying webhook delivery: %s", err)
+	}
+
+	rsp := &pb.ReplayWebhookDeliveryResponse{
+		Delivery: convertWebhookDelivery(delivery),
+	}
+	return rsp, nil
+}
+
+func validateReplayWebhookDeliveryReq(req *pb.ReplayWebhookDeliveryRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.SubscriptionId < 1 {
+		violations = append(violations, ViolationErr("subscription_id", "must be greater than 0"))
+	}
+	if req.DeliveryId < 1 {
+		violations = append(violations, ViolationErr("delivery_id", "must be greater than 0"))
+	}
+
+	return violations
+}
@@ -0,0 +1,58 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+var errEmailAlreadyVerified = errors.New("email is already verified")
+
+// ResendVerifyEmail mails a new verification code to the caller, for when the mail sent on signup never arrived or expired
+func (s *Server) ResendVerifyEmail(ctx context.Context, req *pb.ResendVerifyEmailRequest) (*pb.ResendVerifyEmailResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.GetUser(ctx, authPayload.UserName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+	}
+
+	if user.IsEmailVerified {
+		return nil, FailedPreconditionError(ReasonEmailVerified, errEmailAlreadyVerified, nil)
+	}
+
+	secretCode, err := utils.RandomSecret(32)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error generating verification code: %s", err)
+	}
+
+	verifyEmail, err := s.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: utils.HashSecret(secretCode),
+		ExpiredAt:      time.Now().Add(s.config.VerifyEmailDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db err while creating verify email: %s", err)
+	}
+
+	subject, content := mail.NewVerifyEmail(s.config.VerifyEmailURL, user.FullName, verifyEmail.ID, secretCode)
+	if err = s.mailer.SendEmail(ctx, subject, content, []string{user.Email}); err != nil {
+		return nil, status.Errorf(codes.Internal, "error sending verify email: %s", err)
+	}
+
+	return &pb.ResendVerifyEmailResponse{Message: "a new verification email was sent"}, nil
+}
@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if violations := validateResetPasswordReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	hashedPassword, err := utils.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error hashing password: %s", err)
+	}
+
+	result, err := s.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      utils.HashSecret(req.GetToken()),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.InvalidArgument, "reset token is invalid, already used or expired")
+		}
+		return nil, status.Errorf(codes.Internal, "db err while resetting password: %s", err)
+	}
+	s.passwordChanges.Set(result.User.Username, result.User.PasswordChangedAt)
+
+	rsp := &pb.ResetPasswordResponse{
+		Message: "password updated, please log in again",
+	}
+	return rsp, nil
+}
+
+func validateResetPasswordReq(req *pb.ResetPasswordRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateSecretCode(req.Token); err != nil {
+		violations = append(violations, ViolationErr("token", err.Error()))
+	}
+	if err := validator.ValidatePassword(req.Password); err != nil {
+		violations = append(violations, ViolationErr("password", err.Error()))
+	}
+
+	return violations
+}
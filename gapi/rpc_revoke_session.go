@@ -0,0 +1,44 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeSession blocks one session of the user, its refresh token can't be used anymore
+func (s *Server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := uuid.Parse(req.GetSessionId())
+	if err != nil {
+		return nil, InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			ViolationErr("session_id", "must be a valid uuid"),
+		})
+	}
+
+	_, err = s.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       sessionID,
+		Username: authPayload.UserName,
+	})
+	if err != nil {
+		// sessions of other users are reported as not found
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "db err while revoking session: %s", err)
+	}
+
+	rsp := &pb.RevokeSessionResponse{
+		Message: "session revoked",
+	}
+	return rsp, nil
+}
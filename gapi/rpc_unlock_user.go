@@ -0,0 +1,44 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnlockUser clears the failed logins of a user locked out by the brute-force protection, only admins can call it
+func (s *Server) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.Allowed(authPayload.Role, policy.ManageUsers) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", policy.ErrForbidden)
+	}
+
+	if violations := validateUnlockUserReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	if err = s.lockout.Unlock(ctx, req.GetUsername()); err != nil {
+		return nil, status.Errorf(codes.Internal, "error unlocking user: %s", err)
+	}
+
+	rsp := &pb.UnlockUserResponse{
+		Message: "user unlocked",
+	}
+	return rsp, nil
+}
+
+func validateUnlockUserReq(req *pb.UnlockUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateUsername(req.Username); err != nil {
+		violations = append(violations, ViolationErr("username", err.Error()))
+	}
+
+	return violations
+}
@@ -0,0 +1,155 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/mail"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/policy"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const (
+	updateFullName = "full_name"
+	updateEmail    = "email"
+	updatePassword = "password"
+)
+
+var errWrongPassword = errors.New("current password is incorrect")
+
+// UpdateUser changes the fields of the user named in the update mask, admins can update every user.
+// The email and the password are only changed with the current password of the caller, a token alone isn't enough.
+// A new password logs the user out of every session and a new email has to be verified again
+func (s *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateUpdateUserReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	if err = policy.Authorize(authPayload, policy.UpdateUser, req.GetUsername()); err != nil {
+		return nil, PermissionDeniedError(err)
+	}
+
+	if updatesCredentials(req) {
+		caller, err := s.store.GetUser(ctx, authPayload.UserName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, UnauthenticatedError(err)
+			}
+			return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+		}
+		if err = utils.CheckPassword(req.GetCurrentPassword(), caller.HashedPassword); err != nil {
+			return nil, UnauthenticatedError(errWrongPassword)
+		}
+	}
+
+	arg := db.UpdateUserTxParams{
+		Username: req.GetUsername(),
+	}
+	var secretCode string
+
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case updateFullName:
+			arg.FullName = sql.NullString{String: req.GetFullName(), Valid: true}
+		case updateEmail:
+			arg.Email = sql.NullString{String: req.GetEmail(), Valid: true}
+			secretCode, err = utils.RandomSecret(32)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "error generating verification code: %s", err)
+			}
+			arg.VerifyEmailSecretCodeHash = utils.HashSecret(secretCode)
+			arg.VerifyEmailExpiredAt = time.Now().Add(s.config.VerifyEmailDuration)
+		case updatePassword:
+			hashedPassword, err := utils.HashPassword(req.GetPassword())
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "error hashing password: %s", err)
+			}
+			arg.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+		}
+	}
+
+	result, err := s.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, status.Errorf(codes.AlreadyExists, "email already in use: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "db err while updating user: %s", err)
+	}
+
+	if arg.HashedPassword.Valid {
+		s.passwordChanges.Set(result.User.Username, result.User.PasswordChangedAt)
+	}
+
+	// the code is mailed once the change is committed, if the mail fails the email stays unverified
+	if result.VerifyEmail != nil {
+		subject, content := mail.NewVerifyEmail(s.config.VerifyEmailURL, result.User.FullName, result.VerifyEmail.ID, secretCode)
+		if err = s.mailer.SendEmail(ctx, subject, content, []string{result.User.Email}); err != nil {
+			log.Error().Err(err).Str("user", result.User.Username).Msg("cannot send verify email")
+		}
+	}
+
+	return &pb.UpdateUserResponse{User: convertUser(result.User)}, nil
+}
+
+func validateUpdateUserReq(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, ViolationErr("username", err.Error()))
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		violations = append(violations, ViolationErr("update_mask", "must name at least one field"))
+	}
+
+	for _, path := range paths {
+		switch path {
+		case updateFullName:
+			if err := validator.ValidateFullName(req.GetFullName()); err != nil {
+				violations = append(violations, ViolationErr("full_name", err.Error()))
+			}
+		case updateEmail:
+			if err := validator.ValidateEmail(req.GetEmail()); err != nil {
+				violations = append(violations, ViolationErr("email", err.Error()))
+			}
+		case updatePassword:
+			if err := validator.ValidatePassword(req.GetPassword()); err != nil {
+				violations = append(violations, ViolationErr("password", err.Error()))
+			}
+		default:
+			violations = append(violations, ViolationErr("update_mask", "unsupported field: "+path))
+		}
+	}
+
+	if updatesCredentials(req) && req.GetCurrentPassword() == "" {
+		violations = append(violations, ViolationErr("current_password", "is required to update the email or the password"))
+	}
+
+	return violations
+}
+
+// updatesCredentials reports whether the update changes the email or the password, either of them can take over the account
+func updatesCredentials(req *pb.UpdateUserRequest) bool {
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		if path == updateEmail || path == updatePassword {
+			return true
+		}
+	}
+	return false
+}
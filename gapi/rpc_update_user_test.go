@@ -0,0 +1,262 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"strings"
+	"testing"
+)
+
+func TestUpdateUserRPC(t *testing.T) {
+	password := utils.RandomString(8)
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{
+		// usernames are lowercase
+		Username:       strings.ToLower(utils.RandomOwner()),
+		FullName:       utils.RandomOwner(),
+		Email:          utils.RandomEmail(),
+		HashedPassword: hashedPassword,
+		Role:           utils.CustomerRole,
+	}
+	newName := utils.RandomOwner()
+	newEmail := utils.RandomEmail()
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T) context.Context
+		checkResponse func(t *testing.T, res *pb.UpdateUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				FullName:   newName,
+				Email:      "ignored because it's not in the mask",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"full_name"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, sql.NullString{String: newName, Valid: true}, arg.FullName)
+						require.False(t, arg.Email.Valid)
+						require.False(t, arg.HashedPassword.Valid)
+
+						updated := user
+						updated.FullName = newName
+						return db.UpdateUserTxResult{User: updated}, nil
+					})
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, newName, res.GetUser().GetFullName())
+				require.Equal(t, user.Email, res.GetUser().GetEmail())
+			},
+		},
+		{
+			name: "admin updates another user",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				FullName:   newName,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"full_name"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateUserTxResult{User: user}, nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, utils.RandomOwner(), utils.AdminRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "customer updates another user",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				FullName:   newName,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"full_name"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, utils.RandomOwner(), utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "empty update mask",
+			req: &pb.UpdateUserRequest{
+				Username: user.Username,
+				FullName: newName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "unsupported field in the mask",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"role"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "invalid email",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				Email:      "invalid-email",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "email with the current password",
+			req: &pb.UpdateUserRequest{
+				Username:        user.Username,
+				Email:           newEmail,
+				CurrentPassword: password,
+				UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, sql.NullString{String: newEmail, Valid: true}, arg.Email)
+						require.NotEmpty(t, arg.VerifyEmailSecretCodeHash)
+
+						updated := user
+						updated.Email = newEmail
+						return db.UpdateUserTxResult{
+							User:        updated,
+							VerifyEmail: &db.VerifyEmail{ID: 1, Username: user.Username, Email: newEmail},
+						}, nil
+					})
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, newEmail, res.GetUser().GetEmail())
+			},
+		},
+		{
+			name: "password without the current password",
+			req: &pb.UpdateUserRequest{
+				Username:   user.Username,
+				Password:   utils.RandomString(8),
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"password"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "wrong current password",
+			req: &pb.UpdateUserRequest{
+				Username:        user.Username,
+				Email:           newEmail,
+				CurrentPassword: "wrong-password",
+				UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, user.Username, utils.CustomerRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			// admins confirm their own password, not the password of the user
+			name: "admin updates the password of another user",
+			req: &pb.UpdateUserRequest{
+				Username:        user.Username,
+				Password:        utils.RandomString(8),
+				CurrentPassword: password,
+				UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"password"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				admin := user
+				admin.Username = "admin"
+				admin.Role = utils.AdminRole
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateUserTxResult{User: user}, nil)
+			},
+			buildContext: func(t *testing.T) context.Context {
+				return contextWithPayload(t, "admin", utils.AdminRole)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.UpdateUser(tc.buildContext(t), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
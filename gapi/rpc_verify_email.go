@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if violations := validateVerifyEmailReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	result, err := s.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:        req.GetEmailId(),
+		SecretCodeHash: utils.HashSecret(req.GetSecretCode()),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.InvalidArgument, "verification code is invalid, already used or expired")
+		}
+		return nil, status.Errorf(codes.Internal, "db err while verifying email: %s", err)
+	}
+
+	rsp := &pb.VerifyEmailResponse{
+		User: convertUser(result.User),
+	}
+	return rsp, nil
+}
+
+func validateVerifyEmailReq(req *pb.VerifyEmailRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.EmailId < 1 {
+		violations = append(violations, ViolationErr("email_id", "must be greater than 0"))
+	}
+	if err := validator.ValidateSecretCode(req.SecretCode); err != nil {
+		violations = append(violations, ViolationErr("secret_code", err.Error()))
+	}
+
+	return violations
+}
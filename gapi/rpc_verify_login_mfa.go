@@ -0,0 +1,47 @@
+package gapi
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/mfa"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) VerifyLoginMfa(ctx context.Context, req *pb.VerifyLoginMfaRequest) (*pb.LoginUserResponse, error) {
+	if violations := validateVerifyLoginMfaReq(req); violations != nil {
+		return nil, InvalidArgumentError(violations)
+	}
+
+	username, err := s.mfa.VerifyChallenge(ctx, req.GetMfaToken(), req.GetCode())
+	if err != nil {
+		if locked, ok := err.(*mfa.LockedError); ok {
+			return nil, LockedError(err, locked.RetryAfter)
+		}
+		switch err {
+		case mfa.ErrInvalidChallenge, mfa.ErrInvalidCode, mfa.ErrNotEnrolled:
+			return nil, status.Errorf(codes.Unauthenticated, "unauthorized user: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "error verifying mfa code: %s", err)
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error getting user: %s", err)
+	}
+
+	return s.createLoginSession(ctx, user)
+}
+
+func validateVerifyLoginMfaReq(req *pb.VerifyLoginMfaRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateSecretCode(req.MfaToken); err != nil {
+		violations = append(violations, ViolationErr("mfa_token", err.Error()))
+	}
+	if err := validator.ValidateLength(req.Code, 6, 20); err != nil {
+		violations = append(violations, ViolationErr("code", err.Error()))
+	}
+
+	return violations
+}
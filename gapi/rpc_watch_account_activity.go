@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"github.com/micaelapucciariello/simplebank/activity"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// defaultActivityCheckInterval is how often the credentials of an open stream are checked again
+const defaultActivityCheckInterval = 15 * time.Second
+
+// WatchAccountActivity streams the entries of the accounts of the caller as transfers commit.
+// Clients resume after a disconnection sending the entry_id of the last activity they got.
+// The stream ends when the access token expires or its credentials are no longer valid, see reauthorize
+func (s *Server) WatchAccountActivity(req *pb.WatchAccountActivityRequest, stream pb.SimpleBank_WatchAccountActivityServer) error {
+	ctx := stream.Context()
+	authPayload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if req.GetAfterEntryId() < 0 {
+		return InvalidArgumentError([]*errdetails.BadRequest_FieldViolation{ViolationErr("after_entry_id", "must not be negative")})
+	}
+
+	// subscribing before the replay keeps the transfers committed meanwhile, the replayed ones are skipped
+	events, cancel := s.activity.Subscribe(authPayload.UserName)
+	defer cancel()
+
+	var replayed map[int64]bool
+	if req.GetAfterEntryId() > 0 {
+		replayed, err = activity.Replay(ctx, s.store, authPayload.UserName, req.GetAfterEntryId(), func(event db.AccountActivity) error {
+			return stream.Send(&pb.WatchAccountActivityResponse{Activity: convertAccountActivity(event)})
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot replay account activity: %s", err)
+		}
+	}
+
+	// API keys without expiration have no expiry, a nil channel never fires
+	var expired <-chan time.Time
+	if !authPayload.ExpiredAt.IsZero() {
+		expiry := time.NewTimer(time.Until(authPayload.ExpiredAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	interval := s.config.ActivityHeartbeatInterval
+	if interval <= 0 {
+		interval = defaultActivityCheckInterval
+	}
+	check := time.NewTicker(interval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-expired:
+			return UnauthenticatedError(token.ErrExpiredToken)
+		case <-check.C:
+			if err = s.reauthorize(ctx, authPayload); err != nil {
+				return err
+			}
+		case event, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "account activity interrupted, resume after the last entry_id received")
+			}
+			if replayed[event.EntryID] {
+				continue
+			}
+
+			if err = stream.Send(&pb.WatchAccountActivityResponse{Activity: convertAccountActivity(event)}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/activity"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+type testActivityStream struct {
+	testServerStream
+	sent []*pb.AccountActivity
+	// onSend stops the stream once the test got what it expects
+	onSend func(sent []*pb.AccountActivity)
+}
+
+func (s *testActivityStream) Send(rsp *pb.WatchAccountActivityResponse) error {
+	s.sent = append(s.sent, rsp.GetActivity())
+	s.onSend(s.sent)
+	return nil
+}
+
+func TestWatchAccountActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	owner := utils.RandomOwner()
+	ctx, cancel := context.WithCancel(contextWithPayload(t, owner, utils.CustomerRole))
+	defer cancel()
+
+	store.EXPECT().
+		ListAccountActivity(gomock.Any(), gomock.Eq(db.ListAccountActivityParams{
+			Owner: owner,
+			ID:    4,
+			Limit: activity.ReplayPageSize,
+		})).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.ListAccountActivityParams) ([]db.ListAccountActivityRow, error) {
+			// a transfer commits during the replay, entry 6 is notified and also listed
+			server.activity.Publish(db.AccountActivity{EntryID: 6, AccountID: 1, Owner: owner, Amount: 20, Balance: 130})
+			server.activity.Publish(db.AccountActivity{EntryID: 7, AccountID: 1, Owner: owner, Amount: -5, Balance: 125})
+			server.activity.Publish(db.AccountActivity{EntryID: 8, AccountID: 2, Owner: utils.RandomOwner(), Amount: 5})
+
+			now := sql.NullTime{Time: time.Now(), Valid: true}
+			return []db.ListAccountActivityRow{
+				{ID: 5, AccountID: 1, Amount: 10, Balance: 110, CreatedAt: now},
+				{ID: 6, AccountID: 1, Amount: 20, Balance: 130, CreatedAt: now},
+			}, nil
+		})
+
+	stream := &testActivityStream{
+		testServerStream: testServerStream{ctx: ctx},
+		onSend: func(sent []*pb.AccountActivity) {
+			if len(sent) == 3 {
+				cancel()
+			}
+		},
+	}
+
+	err := server.WatchAccountActivity(&pb.WatchAccountActivityRequest{AfterEntryId: 4}, stream)
+	require.NoError(t, err)
+
+	require.Len(t, stream.sent, 3)
+	for i, entryID := range []int64{5, 6, 7} {
+		require.Equal(t, entryID, stream.sent[i].GetEntryId())
+	}
+	require.Equal(t, int64(125), stream.sent[2].GetBalance())
+}
+
+func TestWatchAccountActivityFallsBehind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	owner := utils.RandomOwner()
+	store.EXPECT().
+		ListAccountActivity(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.ListAccountActivityParams) ([]db.ListAccountActivityRow, error) {
+			// more activity than the subscription buffers drops the stream
+			for i := int64(1); i <= 100; i++ {
+				server.activity.Publish(db.AccountActivity{EntryID: arg.ID + i, Owner: owner})
+			}
+			return []db.ListAccountActivityRow{}, nil
+		})
+
+	stream := &testActivityStream{
+		testServerStream: testServerStream{ctx: contextWithPayload(t, owner, utils.CustomerRole)},
+		onSend:           func(sent []*pb.AccountActivity) {},
+	}
+
+	err := server.WatchAccountActivity(&pb.WatchAccountActivityRequest{AfterEntryId: 1}, stream)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.NotEmpty(t, stream.sent)
+}
+
+func TestWatchAccountActivityEndsWithCredentials(t *testing.T) {
+	owner := utils.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		payload    func(t *testing.T) *token.Payload
+	}{
+		{
+			name:       "access token expires",
+			buildStubs: func(store *mockdb.MockStore) {},
+			payload: func(t *testing.T) *token.Payload {
+				payload, err := token.NewPayload(owner, utils.CustomerRole, 50*time.Millisecond)
+				require.NoError(t, err)
+				return payload
+			},
+		},
+		{
+			name: "password changed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(owner)).
+					AnyTimes().
+					Return(time.Now().Add(time.Hour), nil)
+			},
+			payload: func(t *testing.T) *token.Payload {
+				payload, err := token.NewPayload(owner, utils.CustomerRole, time.Minute)
+				require.NoError(t, err)
+				return payload
+			},
+		},
+		{
+			name: "oauth consent revoked",
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := db.OauthConsent{RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+				store.EXPECT().GetOauthConsent(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+				store.EXPECT().GetOauthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{Owner: utils.RandomOwner()}, nil)
+			},
+			payload: func(t *testing.T) *token.Payload {
+				payload, err := token.NewScopedPayload(owner, utils.RandomString(16), []string{"accounts:read"}, time.Minute)
+				require.NoError(t, err)
+				return payload
+			},
+		},
+		{
+			name: "api key revoked",
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := db.ApiKey{ID: 1, Username: owner, RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+				store.EXPECT().GetApiKey(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(revoked, nil)
+			},
+			payload: func(t *testing.T) *token.Payload {
+				return &token.Payload{UserName: owner, APIKeyID: 1, Scopes: []string{"accounts:read"}}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.ActivityHeartbeatInterval = 10 * time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), payloadKey{}, tc.payload(t)), time.Second)
+			defer cancel()
+			stream := &testActivityStream{
+				testServerStream: testServerStream{ctx: ctx},
+				onSend:           func(sent []*pb.AccountActivity) {},
+			}
+
+			err := server.WatchAccountActivity(&pb.WatchAccountActivityRequest{}, stream)
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+			require.NoError(t, ctx.Err())
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"strings"
+)
+
+// GatewayTracing is a runtime.WithMetadata annotator naming the span of the gateway request after the route it
+// matched. The W3C trace context of the request is passed in the gRPC metadata, like a gRPC client sends it
+func GatewayTracing(ctx context.Context, r *http.Request) metadata.MD {
+	span := trace.SpanFromContext(ctx)
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		span.SetName(r.Method + " " + pattern)
+		span.SetAttributes(semconv.HTTPRoute(pattern))
+	}
+	if method, ok := runtime.RPCMethod(ctx); ok {
+		service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+		span.SetAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(name))
+	}
+
+	md := metadata.MD{}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return md
+}
+
+// metadataCarrier lets the propagators read and write the trace context in gRPC metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
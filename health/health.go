@@ -0,0 +1,186 @@
+// Package health reports whether the service is alive and ready to serve, over HTTP and the gRPC health protocol
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+const (
+	checkDatabase   = "database"
+	checkMigrations = "migrations"
+	checkShutdown   = "shutdown"
+
+	checkOK = "ok"
+)
+
+var errShuttingDown = errors.New("the server is shutting down")
+
+// Status is the body of the health endpoints, Checks holds the result of each readiness check
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Checker checks that the database is reachable and migrated to the version this build expects.
+// The result is served on the readiness endpoint and as the status of the gRPC health service
+type Checker struct {
+	ping             func(ctx context.Context) error
+	migrationVersion func(ctx context.Context) (version uint, dirty bool, err error)
+	expectedVersion  uint
+	timeout          time.Duration
+	server           *grpchealth.Server
+	shuttingDown     atomic.Bool
+}
+
+// NewChecker returns a Checker of conn expecting the schema at expectedVersion or newer, each check of the
+// database times out after timeout. The service is not serving until the first check passes
+func NewChecker(conn *sql.DB, expectedVersion uint, timeout time.Duration) *Checker {
+	checker := &Checker{
+		ping: conn.PingContext,
+		migrationVersion: func(ctx context.Context) (version uint, dirty bool, err error) {
+			err = conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+			return
+		},
+		expectedVersion: expectedVersion,
+		timeout:         timeout,
+		server:          grpchealth.NewServer(),
+	}
+	checker.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return checker
+}
+
+// Register adds the grpc.health.v1 service to the gRPC server
+func (c *Checker) Register(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, c.server)
+}
+
+// Check runs the readiness checks, it fails once the shutdown started
+func (c *Checker) Check(ctx context.Context) (Status, error) {
+	if c.shuttingDown.Load() {
+		return Status{
+			Status: healthpb.HealthCheckResponse_NOT_SERVING.String(),
+			Checks: map[string]string{checkShutdown: errShuttingDown.Error()},
+		}, errShuttingDown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := Status{
+		Status: healthpb.HealthCheckResponse_SERVING.String(),
+		Checks: map[string]string{checkDatabase: checkOK, checkMigrations: checkOK},
+	}
+
+	var err error
+	if err = c.ping(ctx); err != nil {
+		status.Checks[checkDatabase] = err.Error()
+		status.Checks[checkMigrations] = "database unreachable"
+	} else {
+		err = c.checkMigrations(ctx)
+		if err != nil {
+			status.Checks[checkMigrations] = err.Error()
+		}
+	}
+
+	if err != nil {
+		status.Status = healthpb.HealthCheckResponse_NOT_SERVING.String()
+	}
+	return status, err
+}
+
+// checkMigrations fails while a migration is half applied or the schema is older than this build.
+// Newer schemas are accepted, migrations run before the new build replaces the old one
+func (c *Checker) checkMigrations(ctx context.Context) error {
+	version, dirty, err := c.migrationVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get migration version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < c.expectedVersion {
+		return fmt.Errorf("migration version %d is older than %d", version, c.expectedVersion)
+	}
+	return nil
+}
+
+// Run checks the readiness every interval and updates the status of the gRPC health service.
+// When ctx is done the shutdown started, so it reports NOT_SERVING and returns
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			c.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) update(ctx context.Context) {
+	if _, err := c.Check(ctx); err != nil {
+		log.Warn().Err(err).Msg("service not ready")
+		c.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+		return
+	}
+	c.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+}
+
+// setServingStatus sets the status of the server and of the SimpleBank service
+func (c *Checker) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	c.server.SetServingStatus("", status)
+	c.server.SetServingStatus(pb.SimpleBank_ServiceDesc.ServiceName, status)
+}
+
+// Shutdown reports NOT_SERVING from now on, so clients stop sending requests before the servers stop
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+	c.server.Shutdown()
+}
+
+// ServeLiveness answers while the process can serve requests, the database isn't checked so an outage of
+// Postgres doesn't get the service restarted
+func (c *Checker) ServeLiveness(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, Status{Status: healthpb.HealthCheckResponse_SERVING.String()})
+}
+
+// ServeReadiness runs the readiness checks, it answers 503 when one of them fails
+func (c *Checker) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	status, err := c.Check(r.Context())
+	if err != nil {
+		writeStatus(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeStatus(w, http.StatusOK, status)
+}
+
+func writeStatus(w http.ResponseWriter, statusCode int, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Error().Err(err).Msg("cannot write health status")
+	}
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/stretchr/testify/require"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testVersion = 202404
+
+func newTestChecker(pingErr error, version uint, dirty bool, versionErr error) *Checker {
+	checker := &Checker{
+		ping: func(ctx context.Context) error {
+			return pingErr
+		},
+		migrationVersion: func(ctx context.Context) (uint, bool, error) {
+			return version, dirty, versionErr
+		},
+		expectedVersion: testVersion,
+		timeout:         time.Second,
+		server:          grpchealth.NewServer(),
+	}
+	checker.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return checker
+}
+
+func TestServeReadiness(t *testing.T) {
+	testCases := []struct {
+		name       string
+		checker    *Checker
+		statusCode int
+		checks     map[string]string
+	}{
+		{
+			name:       "ready",
+			checker:    newTestChecker(nil, testVersion, false, nil),
+			statusCode: http.StatusOK,
+			checks:     map[string]string{checkDatabase: checkOK, checkMigrations: checkOK},
+		},
+		{
+			name:       "newer schema",
+			checker:    newTestChecker(nil, testVersion+1, false, nil),
+			statusCode: http.StatusOK,
+			checks:     map[string]string{checkDatabase: checkOK, checkMigrations: checkOK},
+		},
+		{
+			name:       "database unreachable",
+			checker:    newTestChecker(errors.New("connection refused"), 0, false, nil),
+			statusCode: http.StatusServiceUnavailable,
+			checks:     map[string]string{checkDatabase: "connection refused", checkMigrations: "database unreachable"},
+		},
+		{
+			name:       "older schema",
+			checker:    newTestChecker(nil, testVersion-1, false, nil),
+			statusCode: http.StatusServiceUnavailable,
+			checks:     map[string]string{checkDatabase: checkOK, checkMigrations: "migration version 202403 is older than 202404"},
+		},
+		{
+			name:       "dirty migration",
+			checker:    newTestChecker(nil, testVersion, true, nil),
+			statusCode: http.StatusServiceUnavailable,
+			checks:     map[string]string{checkDatabase: checkOK, checkMigrations: "migration 202404 is dirty"},
+		},
+		{
+			name:       "migrations table missing",
+			checker:    newTestChecker(nil, 0, false, errors.New(`relation "schema_migrations" does not exist`)),
+			statusCode: http.StatusServiceUnavailable,
+			checks: map[string]string{
+				checkDatabase:   checkOK,
+				checkMigrations: `cannot get migration version: relation "schema_migrations" does not exist`,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			tc.checker.ServeReadiness(recorder, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+			require.Equal(t, tc.statusCode, recorder.Code)
+
+			var status Status
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&status))
+			require.Equal(t, tc.checks, status.Checks)
+			if tc.statusCode == http.StatusOK {
+				require.Equal(t, healthpb.HealthCheckResponse_SERVING.String(), status.Status)
+			} else {
+				require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING.String(), status.Status)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	checker := newTestChecker(nil, testVersion, false, nil)
+	request := &healthpb.HealthCheckRequest{Service: pb.SimpleBank_ServiceDesc.ServiceName}
+
+	// the service is not serving until the first check passes
+	rsp, err := checker.server.Check(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+
+	checker.update(context.Background())
+	rsp, err = checker.server.Check(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.Status)
+
+	checker.Shutdown()
+	rsp, err = checker.server.Check(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+
+	// checks passing after the shutdown started don't bring the service back
+	checker.update(context.Background())
+	rsp, err = checker.server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+
+	recorder := httptest.NewRecorder()
+	checker.ServeReadiness(recorder, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// the process is still alive while it drains
+	recorder = httptest.NewRecorder()
+	checker.ServeLiveness(recorder, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestRunStopsServing(t *testing.T) {
+	checker := newTestChecker(nil, testVersion, false, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		rsp, err := checker.server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && rsp.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	rsp, err := checker.server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+}
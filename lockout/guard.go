@@ -0,0 +1,162 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"time"
+)
+
+const (
+	ScopeUsername = "username"
+	ScopeClientIP = "client_ip"
+)
+
+var ErrLocked = errors.New("too many failed login attempts, try again later")
+
+// Guard tracks failed logins per username and per client IP in Postgres, so every server instance shares them.
+// Every attempt is counted before the password is checked and a successful one is taken back. Each failure of a
+// username delays its next attempt exponentially, and both the username and the client IP are locked out once
+// they reach their max failures within the window
+type Guard struct {
+	store           db.Store
+	maxUserFailures int32
+	maxIPFailures   int32
+	window          time.Duration
+	baseDelay       time.Duration
+	lockoutDuration time.Duration
+}
+
+// NewGuard reads the limits from the LOGIN_* settings, a zero max failures disables the lockout of its scope
+func NewGuard(store db.Store, config utils.Config) *Guard {
+	return &Guard{
+		store:           store,
+		maxUserFailures: config.LoginMaxUserFailures,
+		maxIPFailures:   config.LoginMaxIPFailures,
+		window:          config.LoginFailureWindow,
+		baseDelay:       config.LoginFailureDelay,
+		lockoutDuration: config.LoginLockoutDuration,
+	}
+}
+
+// Attempt counts a login attempt of the client IP and the username before the password is checked, and returns
+// ErrLocked and the time left when either of them can't try to log in yet. The count and the max failures check
+// are a single statement, so concurrent attempts can't all get in before their failures are recorded
+func (g *Guard) Attempt(ctx context.Context, username string, clientIP string) (time.Duration, error) {
+	locked, err := g.attempt(ctx, ScopeClientIP, clientIP, g.maxIPFailures)
+	if err == nil && !locked {
+		locked, err = g.attempt(ctx, ScopeUsername, username, g.maxUserFailures)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	return g.retryAfter(ctx, username, clientIP)
+}
+
+func (g *Guard) attempt(ctx context.Context, scope string, identifier string, maxFailures int32) (bool, error) {
+	if identifier == "" {
+		return false, nil
+	}
+
+	now := time.Now()
+	_, err := g.store.RecordLoginAttempt(ctx, db.RecordLoginAttemptParams{
+		Scope:       scope,
+		Identifier:  identifier,
+		MaxFailures: maxFailures,
+		LockUntil:   now.Add(g.lockoutDuration),
+		WindowStart: now.Add(-g.window),
+	})
+	if err != nil {
+		// a locked scope isn't updated, so the statement returns no row
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, fmt.Errorf("cannot record login attempt: %w", err)
+	}
+	return false, nil
+}
+
+// retryAfter returns ErrLocked and the time left until the username and the client IP can try to log in again
+func (g *Guard) retryAfter(ctx context.Context, username string, clientIP string) (time.Duration, error) {
+	failures, err := g.store.ListLoginFailures(ctx, db.ListLoginFailuresParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot list login failures: %w", err)
+	}
+
+	var retryAfter time.Duration
+	now := time.Now()
+	for _, failure := range failures {
+		if !failure.LockedUntil.Valid {
+			continue
+		}
+		if wait := failure.LockedUntil.Time.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, ErrLocked
+}
+
+// RecordFailure delays the next attempt of the username by baseDelay * 2^(failures-1), capped at the lockout.
+// The attempt was already counted by Attempt. Unknown usernames are delayed too, so the responses don't reveal
+// which exist
+func (g *Guard) RecordFailure(ctx context.Context, username string) error {
+	if g.baseDelay <= 0 {
+		return nil
+	}
+
+	err := g.store.DelayLogin(ctx, db.DelayLoginParams{
+		BaseDelaySeconds: g.baseDelay.Seconds(),
+		MaxDelaySeconds:  g.lockoutDuration.Seconds(),
+		Scope:            ScopeUsername,
+		Identifier:       username,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delay login: %w", err)
+	}
+	return nil
+}
+
+// RecordSuccess clears the failures of the username and takes the attempt back from the count of the client IP,
+// so the users behind the same address don't lock each other out by logging in. An empty username or client IP is
+// skipped, the failures of a user with 2FA are only cleared once the code is verified
+func (g *Guard) RecordSuccess(ctx context.Context, username string, clientIP string) error {
+	if username != "" {
+		if err := g.Unlock(ctx, username); err != nil {
+			return err
+		}
+	}
+	if clientIP == "" {
+		return nil
+	}
+
+	err := g.store.ForgetLoginAttempt(ctx, db.ForgetLoginAttemptParams{
+		MaxFailures: g.maxIPFailures,
+		Scope:       ScopeClientIP,
+		Identifier:  clientIP,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot forget login attempt: %w", err)
+	}
+	return nil
+}
+
+// Unlock removes the failures and the lockout of a username before they expire
+func (g *Guard) Unlock(ctx context.Context, username string) error {
+	err := g.store.DeleteLoginFailure(ctx, db.DeleteLoginFailureParams{
+		Scope:      ScopeUsername,
+		Identifier: username,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestGuard(store db.Store) *Guard {
+	return NewGuard(store, utils.Config{
+		LoginMaxUserFailures: 5,
+		LoginMaxIPFailures:   20,
+		LoginFailureWindow:   15 * time.Minute,
+		LoginFailureDelay:    time.Second,
+		LoginLockoutDuration: 15 * time.Minute,
+	})
+}
+
+func TestAttempt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.RecordLoginAttemptParams) (db.LoginFailure, error) {
+			switch arg.Scope {
+			case ScopeClientIP:
+				require.Equal(t, int32(20), arg.MaxFailures)
+			case ScopeUsername:
+				require.Equal(t, int32(5), arg.MaxFailures)
+			}
+			require.WithinDuration(t, time.Now().Add(-15*time.Minute), arg.WindowStart, time.Second)
+			require.WithinDuration(t, time.Now().Add(15*time.Minute), arg.LockUntil, time.Second)
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedCount: 1}, nil
+		})
+
+	_, err := guard.Attempt(context.Background(), "alice", "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestAttemptLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	// a locked scope isn't counted, the statement returns no row
+	gomock.InOrder(
+		store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.LoginFailure{FailedCount: 1}, nil),
+		store.EXPECT().RecordLoginAttempt(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.LoginFailure{}, sql.ErrNoRows),
+	)
+	store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.LoginFailure{
+			{Scope: ScopeUsername, LockedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}},
+			{Scope: ScopeClientIP, LockedUntil: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}},
+		}, nil)
+
+	retryAfter, err := guard.Attempt(context.Background(), "alice", "10.0.0.1")
+	require.ErrorIs(t, err, ErrLocked)
+	require.WithinDuration(t, time.Now().Add(time.Hour), time.Now().Add(retryAfter), time.Second)
+}
+
+func TestRecordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	store.EXPECT().DelayLogin(gomock.Any(), gomock.Eq(db.DelayLoginParams{
+		BaseDelaySeconds: 1,
+		MaxDelaySeconds:  900,
+		Scope:            ScopeUsername,
+		Identifier:       "alice",
+	})).
+		Times(1).
+		Return(nil)
+
+	err := guard.RecordFailure(context.Background(), "alice")
+	require.NoError(t, err)
+}
+
+func TestRecordSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{
+		Scope:      ScopeUsername,
+		Identifier: "alice",
+	})).
+		Times(1).
+		Return(nil)
+	store.EXPECT().ForgetLoginAttempt(gomock.Any(), gomock.Eq(db.ForgetLoginAttemptParams{
+		MaxFailures: 20,
+		Scope:       ScopeClientIP,
+		Identifier:  "10.0.0.1",
+	})).
+		Times(1).
+		Return(nil)
+
+	err := guard.RecordSuccess(context.Background(), "alice", "10.0.0.1")
+	require.NoError(t, err)
+}
@@ -0,0 +1,109 @@
+// Package logging sets up the structured JSON logs and carries the fields of the log of each request
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader is the header, and the gRPC metadata key in lower case, clients can send to correlate their logs with ours
+const RequestIDHeader = "X-Request-ID"
+
+var isValidRequestID = regexp.MustCompile("^[a-zA-Z0-9._-]{1,64}$").MatchString
+
+// Setup makes the global logger write JSON to w at the level of the config, info by default
+func Setup(config utils.Config, w io.Writer) error {
+	level := zerolog.InfoLevel
+	if config.LogLevel != "" {
+		var err error
+		level, err = zerolog.ParseLevel(config.LogLevel)
+		if err != nil {
+			return fmt.Errorf("invalid log level %q: %w", config.LogLevel, err)
+		}
+	}
+
+	zerolog.SetGlobalLevel(level)
+	log.Logger = zerolog.New(w).With().Timestamp().Logger()
+	return nil
+}
+
+type requestKey struct{}
+
+// Request holds the fields of the log of a request, the user is known once the request is authenticated
+type Request struct {
+	ID   string
+	mu   sync.Mutex
+	user string
+}
+
+// NewRequest puts the log fields of a new request on ctx. The request id sent by the client is kept when it's valid
+func NewRequest(ctx context.Context, requestID string) (context.Context, *Request) {
+	if !isValidRequestID(requestID) {
+		requestID = newRequestID()
+	}
+
+	request := &Request{ID: requestID}
+	return context.WithValue(ctx, requestKey{}, request), request
+}
+
+// FromContext returns the log fields of the request served with ctx, nil if there are none
+func FromContext(ctx context.Context) *Request {
+	request, _ := ctx.Value(requestKey{}).(*Request)
+	return request
+}
+
+// SetUser records the authenticated caller on the log of the request served with ctx
+func SetUser(ctx context.Context, username string) {
+	if request := FromContext(ctx); request != nil {
+		request.SetUser(username)
+	}
+}
+
+func (r *Request) SetUser(username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.user = username
+}
+
+func (r *Request) User() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.user
+}
+
+// HTTPRequest logs a served HTTP request, server errors at error level
+func HTTPRequest(request *Request, method string, path string, statusCode int, duration time.Duration) {
+	event := log.Info()
+	if statusCode >= http.StatusInternalServerError {
+		event = log.Error()
+	}
+
+	event.Str("protocol", "http").
+		Str("method", method).
+		Str("path", path).
+		Int("status_code", statusCode).
+		Str("status_text", http.StatusText(statusCode)).
+		Dur("duration", duration).
+		Str("request_id", request.ID).
+		Str("user", request.User()).
+		Msg("received an HTTP request")
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// ids only correlate logs, a time based one is good enough
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewRequest(t *testing.T) {
+	ctx, request := NewRequest(context.Background(), "client-request.1")
+	require.Equal(t, "client-request.1", request.ID)
+	require.Same(t, request, FromContext(ctx))
+
+	// ids that could break the logs are replaced
+	_, request = NewRequest(context.Background(), "bad id\n")
+	require.Len(t, request.ID, 32)
+
+	_, other := NewRequest(context.Background(), "")
+	require.Len(t, other.ID, 32)
+	require.NotEqual(t, request.ID, other.ID)
+}
+
+func TestSetUser(t *testing.T) {
+	username := utils.RandomOwner()
+
+	ctx, request := NewRequest(context.Background(), "")
+	SetUser(ctx, username)
+	require.Equal(t, username, request.User())
+
+	// requests served without log fields are ignored
+	SetUser(context.Background(), username)
+	require.Nil(t, FromContext(context.Background()))
+}
+
+func TestSetup(t *testing.T) {
+	defer func(logger zerolog.Logger, level zerolog.Level) {
+		log.Logger = logger
+		zerolog.SetGlobalLevel(level)
+	}(log.Logger, zerolog.GlobalLevel())
+
+	var buf bytes.Buffer
+	require.NoError(t, Setup(utils.Config{LogLevel: "warn"}, &buf))
+	require.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+
+	_, request := NewRequest(context.Background(), "request-id")
+	request.SetUser("user")
+	HTTPRequest(request, http.MethodGet, "/accounts", http.StatusOK, time.Millisecond)
+	require.Empty(t, buf.String())
+
+	HTTPRequest(request, http.MethodGet, "/accounts", http.StatusInternalServerError, time.Millisecond)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	require.Equal(t, "error", fields["level"])
+	require.Equal(t, "request-id", fields["request_id"])
+	require.Equal(t, "user", fields["user"])
+	require.Equal(t, float64(http.StatusInternalServerError), fields["status_code"])
+
+	require.Error(t, Setup(utils.Config{LogLevel: "loud"}, &buf))
+}
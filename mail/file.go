@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every email as an .eml file in dir.
+// It is meant for local development where no SMTP server is available
+type FileMailer struct {
+	dir  string
+	from mail.Address
+}
+
+func NewFileMailer(dir string, senderName string, senderAddress string) Mailer {
+	return &FileMailer{
+		dir:  dir,
+		from: mail.Address{Name: senderName, Address: senderAddress},
+	}
+}
+
+func (m *FileMailer) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		return fmt.Errorf("mail output dir is not configured")
+	}
+
+	msg := buildMessage(m.from, subject, content, to)
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create mail output dir: %w", err)
+	}
+
+	name := filepath.Join(m.dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	if err := os.WriteFile(name, msg, 0o644); err != nil {
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+	log.Info().Strs("to", to).Str("file", name).Msg("email written")
+	return nil
+}
@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"context"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFileMailer(dir, "Simple Bank", "no-reply@simplebank.com")
+
+	err := mailer.SendEmail(context.Background(), "Welcome", "<h1>Hello</h1>", []string{"user@example.com"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), "To: user@example.com")
+	require.Contains(t, string(data), "Subject: Welcome")
+	require.Contains(t, string(data), "<h1>Hello</h1>")
+}
+
+func TestFileMailerNoRecipients(t *testing.T) {
+	mailer := NewFileMailer(t.TempDir(), "Simple Bank", "no-reply@simplebank.com")
+
+	err := mailer.SendEmail(context.Background(), "Welcome", "<h1>Hello</h1>", nil)
+	require.Error(t, err)
+}
+
+func TestNewMailer(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config utils.Config
+		valid  bool
+	}{
+		{
+			name:   "file driver",
+			config: utils.Config{MailerDriver: DriverFile, MailOutputDir: t.TempDir()},
+			valid:  true,
+		},
+		{
+			name:   "smtp driver",
+			config: utils.Config{MailerDriver: DriverSMTP},
+			valid:  true,
+		},
+		{
+			name:   "no driver",
+			config: utils.Config{MailOutputDir: t.TempDir()},
+		},
+		{
+			name:   "file driver without output dir",
+			config: utils.Config{MailerDriver: DriverFile},
+		},
+		{
+			name:   "unsupported driver",
+			config: utils.Config{MailerDriver: "log"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			mailer, err := NewMailer(tc.config)
+			if !tc.valid {
+				require.Error(t, err)
+				require.Nil(t, mailer)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, mailer)
+		})
+	}
+}
+
+func TestSendInBackground(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFileMailer(dir, "Simple Bank", "no-reply@simplebank.com")
+
+	SendInBackground(mailer, "Reset", "<h1>Hello</h1>", []string{"user@example.com"})
+
+	require.Eventually(t, func() bool {
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		return err == nil && len(files) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/rs/zerolog/log"
+	"html"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+
+	// backgroundSendTimeout bounds the emails sent once the request is over
+	backgroundSendTimeout = 30 * time.Second
+)
+
+// Mailer sends html emails, the implementation is chosen with MAILER_DRIVER
+type Mailer interface {
+	SendEmail(ctx context.Context, subject string, content string, to []string) error
+}
+
+// SendInBackground sends the email without holding the request. It runs on a context detached from the request with
+// a timeout of its own, so it's neither canceled when the response is written nor left hanging, and failures are logged
+func SendInBackground(mailer Mailer, subject string, content string, to []string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundSendTimeout)
+		defer cancel()
+
+		if err := mailer.SendEmail(ctx, subject, content, to); err != nil {
+			log.Error().Err(err).Str("subject", subject).Msg("cannot send email")
+		}
+	}()
+}
+
+// NewMailer builds the Mailer configured in utils.Config. The driver must be set explicitly, the emails carry secret
+// codes and links so they're never written anywhere by default
+func NewMailer(config utils.Config) (Mailer, error) {
+	switch config.MailerDriver {
+	case DriverSMTP:
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.EmailSenderName, config.EmailSenderAddress), nil
+	case DriverFile:
+		if config.MailOutputDir == "" {
+			return nil, fmt.Errorf("file mailer requires MAIL_OUTPUT_DIR")
+		}
+		return NewFileMailer(config.MailOutputDir, config.EmailSenderName, config.EmailSenderAddress), nil
+	case "":
+		return nil, fmt.Errorf("mailer driver is not configured, set MAILER_DRIVER to %s or %s", DriverSMTP, DriverFile)
+	default:
+		return nil, fmt.Errorf("unsupported mailer driver: %s", config.MailerDriver)
+	}
+}
+
+// buildMessage renders a RFC 5322 message with an html body
+func buildMessage(from mail.Address, subject string, content string, to []string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(content)
+	return msg.Bytes()
+}
+
+// NewVerifyEmail renders the email sent on signup with the link that confirms the address
+func NewVerifyEmail(verifyURL string, fullName string, emailID int64, secretCode string) (subject string, content string) {
+	link := fmt.Sprintf("%s?email_id=%d&secret_code=%s", verifyURL, emailID, secretCode)
+	subject = "Welcome to Simple Bank"
+	content = fmt.Sprintf(`Hello %s,<br/>
+Thank you for registering with us!<br/>
+Please <a href="%s">click here</a> to verify your email address.<br/>`, html.EscapeString(fullName), link)
+	return
+}
+
+// NewResetPasswordEmail renders the email with the single-use link to choose a new password. resetURL is a page of
+// the front-end: it asks for the new password and sends it with the token to the reset password endpoint
+func NewResetPasswordEmail(resetURL string, fullName string, token string) (subject string, content string) {
+	link := fmt.Sprintf("%s?token=%s", resetURL, token)
+	subject = "Reset your Simple Bank password"
+	content = fmt.Sprintf(`Hello %s,<br/>
+We received a request to reset your password.<br/>
+Please <a href="%s">click here</a> to choose a new one. If you didn't ask for it you can ignore this email.<br/>`, html.EscapeString(fullName), link)
+	return
+}
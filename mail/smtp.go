@@ -0,0 +1,97 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPMailer sends emails through an SMTP server using plain auth
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     mail.Address
+}
+
+func NewSMTPMailer(host string, port int, username string, password string, senderName string, senderAddress string) Mailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     mail.Address{Name: senderName, Address: senderAddress},
+	}
+}
+
+func (m *SMTPMailer) SendEmail(ctx context.Context, subject string, content string, to []string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot send email via %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// net/smtp doesn't take a context, the deadline of ctx bounds the whole exchange
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("cannot send email via %s: %w", addr, err)
+		}
+	}
+
+	msg := buildMessage(m.from, subject, content, to)
+	if err = m.send(conn, to, msg); err != nil {
+		return fmt.Errorf("cannot send email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// send runs the same exchange as smtp.SendMail on conn
+func (m *SMTPMailer) send(conn net.Conn, to []string, msg []byte) error {
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -4,18 +4,31 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/micaelapucciariello/simplebank/api"
+	"github.com/micaelapucciariello/simplebank/db/migration"
 	db "github.com/micaelapucciariello/simplebank/db/sqlc"
 	"github.com/micaelapucciariello/simplebank/gapi"
+	"github.com/micaelapucciariello/simplebank/health"
+	"github.com/micaelapucciariello/simplebank/logging"
+	"github.com/micaelapucciariello/simplebank/metrics"
 	"github.com/micaelapucciariello/simplebank/pb"
+	"github.com/micaelapucciariello/simplebank/tracing"
 	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/micaelapucciariello/simplebank/webhook"
 	"github.com/rakyll/statik/fs"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
-	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 	_ "github.com/micaelapucciariello/simplebank/docs/statik"
@@ -24,87 +37,345 @@ import (
 func main() {
 	cfg, err := utils.LoadConfig("")
 	if err != nil {
-		log.Fatal("cannot get config: ", err)
+		log.Fatal().Err(err).Msg("cannot get config")
 	}
+	if err = logging.Setup(cfg, os.Stdout); err != nil {
+		log.Fatal().Err(err).Msg("cannot set up logging")
+	}
+	servers, err := selectServers(cfg.Servers)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot select servers")
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg, os.Stdout)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot set up tracing")
+	}
+
 	conn, err := sql.Open(cfg.DriverName, cfg.SourceName)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot connect to db: %s", err))
+		log.Fatal().Err(err).Msg("cannot connect to db")
 	}
 
-	store := db.NewStore(conn)
-	go runGatewayServer(cfg, store)
-	rungRPCServer(cfg, store)
-}
+	if err = metrics.RegisterDB(conn); err != nil {
+		log.Fatal().Err(err).Msg("cannot register db metrics")
+	}
 
-func runHTTPServer(cfg utils.Config, store db.Store) {
-	server, err := api.NewServer(cfg, store)
+	migrationVersion, err := migration.LatestVersion()
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot initiate http server: %s", err))
+		log.Fatal().Err(err).Msg("cannot get migration version")
 	}
+	checker := health.NewChecker(conn, migrationVersion, cfg.HealthCheckTimeout)
+	store := metrics.NewStore(tracing.NewStore(db.NewStore(conn)))
+	promoteAdmins(context.Background(), store, cfg.AdminUsernames)
 
-	err = server.Start(cfg.HTTPServerAddress)
+	// the gateway and the gRPC server share one server, so they agree on the caches and the activity streams
+	var grpcAPI *gapi.Server
+	if servers[gatewayServer] || servers[grpcServer] || (servers[ginServer] && cfg.GinMountGateway) {
+		grpcAPI, err = gapi.NewServer(cfg, store)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot initiate gRPC server")
+		}
+	}
+
+	// the servers and workers stop when the process is signaled or one of them fails
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	group, ctx := errgroup.WithContext(ctx)
+
+	group.Go(func() error {
+		checker.Run(ctx, cfg.HealthCheckInterval)
+		return nil
+	})
+	group.Go(func() error {
+		runWebhookWorker(ctx, cfg, store)
+		return nil
+	})
+	if grpcAPI != nil {
+		listenAccountActivity(ctx, group, grpcAPI.ListenAccountActivity)
+	}
+
+	if servers[ginServer] {
+		group.Go(func() error {
+			return runGinServer(ctx, group, cfg, store, grpcAPI, checker)
+		})
+	}
+	if servers[gatewayServer] {
+		group.Go(func() error {
+			return runGatewayServer(ctx, group, cfg, grpcAPI, checker)
+		})
+	}
+	if servers[grpcServer] {
+		group.Go(func() error {
+			return rungRPCServer(ctx, cfg, grpcAPI, checker)
+		})
+	}
+
+	err = group.Wait()
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot start http server: %s", err))
+		log.Error().Err(err).Msg("server failed")
 	}
-}
 
-func rungRPCServer(cfg utils.Config, store db.Store) {
-	server, err := gapi.NewServer(cfg, store)
+	// nothing uses the pool once every server and worker returned
+	if err := conn.Close(); err != nil {
+		log.Error().Err(err).Msg("cannot close db")
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Error().Err(err).Msg("cannot flush traces")
+	}
+
+	log.Info().Msg("server stopped")
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot initiate gRPC server: %s", err))
+		os.Exit(1)
 	}
-	grpcServer := grpc.NewServer()
-	pb.RegisterSimpleBankServer(grpcServer, server)
-	reflection.Register(grpcServer)
+}
 
-	listener, err := net.Listen("tcp", cfg.GRPCServerAddress)
+// the servers the binary can run, selected by the SERVERS config
+const (
+	ginServer     = "gin"
+	gatewayServer = "gateway"
+	grpcServer    = "grpc"
+)
+
+// selectServers validates the names of the servers to run, none means the gateway and the gRPC server
+func selectServers(names []string) (map[string]bool, error) {
+	servers := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+		case ginServer, gatewayServer, grpcServer:
+			servers[name] = true
+		default:
+			return nil, fmt.Errorf("unknown server %q, expected %s, %s or %s", name, ginServer, gatewayServer, grpcServer)
+		}
+	}
+	if len(servers) == 0 {
+		servers[gatewayServer] = true
+		servers[grpcServer] = true
+	}
+	return servers, nil
+}
+
+func runGinServer(ctx context.Context, group *errgroup.Group, cfg utils.Config, store db.Store, grpcAPI *gapi.Server, checker *health.Checker) error {
+	server, err := api.NewServer(cfg, store)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot create listener: %s", err))
+		return fmt.Errorf("cannot initiate gin server: %w", err)
+	}
+	listenAccountActivity(ctx, group, server.ListenAccountActivity)
+
+	// the activity streams end once the shutdown starts, it would wait for them until the timeout otherwise
+	httpServer := &http.Server{}
+	httpServer.RegisterOnShutdown(server.CloseAccountActivity)
+
+	server.MountHealth(checker)
+	if cfg.GinMountGateway {
+		grpcServer := newGRPCServer(grpcAPI, checker)
+		httpServer.RegisterOnShutdown(grpcAPI.CloseAccountActivity)
+		gateway, err := gapi.NewGateway(ctx, grpcServer)
+		if err != nil {
+			return err
+		}
+		// a failure of the in-process gRPC server stops the other servers too
+		group.Go(gateway.Serve)
+		// the gateway calls are only stopped once Gin stopped sending them
+		defer closeGateway(gateway, grpcServer, cfg.ShutdownTimeout)
+
+		// Gin logs the gateway requests with its own, the gateway records their metrics by its routes
+		server.MountGateway(gapi.HttpMetrics(gateway))
 	}
 
-	err = grpcServer.Serve(listener)
+	listener, err := net.Listen("tcp", cfg.GinServerAddress)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot start gRPC server: %s", err))
+		return fmt.Errorf("cannot create gin listener: %w", err)
+	}
+	httpServer.Handler = otelhttp.NewHandler(server.Handler(), ginServer, otelhttp.WithFilter(isClientRequest))
+
+	log.Info().Str("address", listener.Addr().String()).Bool("gateway", cfg.GinMountGateway).Msg("gin server started")
+	return serveHTTP(ctx, httpServer, listener, cfg.ShutdownTimeout)
+}
+
+// promoteAdmins grants the admin role to the configured users, the users that don't exist yet are promoted on the next
+// start. A failure is logged and the servers start anyway, the admins keep the role they had
+func promoteAdmins(ctx context.Context, store db.Store, usernames []string) {
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			continue
+		}
+
+		_, err := store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+			Username: username,
+			Role:     utils.AdminRole,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				log.Warn().Str("user", username).Msg("admin user doesn't exist yet")
+				continue
+			}
+			log.Error().Err(err).Str("user", username).Msg("cannot promote admin user")
+			continue
+		}
+		log.Info().Str("user", username).Msg("admin user promoted")
 	}
+}
 
-	log.Printf("gRPC server started at address %v", listener.Addr().String())
+func runWebhookWorker(ctx context.Context, cfg utils.Config, store db.Store) {
+	worker := webhook.NewWorker(store, cfg.WebhookMaxAttempts, cfg.WebhookBackoffBase, cfg.WebhookPollInterval)
+	log.Info().Dur("poll_interval", cfg.WebhookPollInterval).Msg("webhook worker started")
+	worker.Start(ctx)
+	log.Info().Msg("webhook worker stopped")
 }
 
-func runGatewayServer(cfg utils.Config, store db.Store) {
-	server, err := gapi.NewServer(cfg, store)
+// newGRPCServer registers the gRPC services behind the interceptors shared by the gRPC clients and the gateway
+func newGRPCServer(server *gapi.Server, checker *health.Checker) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), gapi.GrpcLogger, gapi.GrpcMetrics, server.AuthUnaryInterceptor),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), gapi.GrpcStreamLogger, gapi.GrpcStreamMetrics, server.AuthStreamInterceptor),
+	)
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	checker.Register(grpcServer)
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+func rungRPCServer(ctx context.Context, cfg utils.Config, server *gapi.Server, checker *health.Checker) error {
+	grpcServer := newGRPCServer(server, checker)
+
+	listener, err := net.Listen("tcp", cfg.GRPCServerAddress)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot initiate gateway server: %s", err))
+		return fmt.Errorf("cannot create gRPC listener: %w", err)
 	}
 
-	grpcMux := runtime.NewServeMux()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(listener)
+	}()
+	log.Info().Str("address", listener.Addr().String()).Msg("gRPC server started")
 
-	// invokes the cancel context function when the execution is completed
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	select {
+	case err = <-serveErr:
+		return fmt.Errorf("gRPC server failed: %w", err)
+	case <-ctx.Done():
+	}
 
-	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
+	log.Info().Msg("shutting down gRPC server")
+	// the activity streams would keep GracefulStop waiting until the timeout
+	server.CloseAccountActivity()
+	stopGRPCServer(grpcServer, cfg.ShutdownTimeout)
+	log.Info().Msg("gRPC server stopped")
+	return nil
+}
+
+func runGatewayServer(ctx context.Context, group *errgroup.Group, cfg utils.Config, server *gapi.Server, checker *health.Checker) error {
+	grpcServer := newGRPCServer(server, checker)
+	gateway, err := gapi.NewGateway(ctx, grpcServer)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot create server handler: %s", err))
+		return err
 	}
+	// a failure of the in-process gRPC server stops the other servers too
+	group.Go(gateway.Serve)
+	// the gateway calls are only stopped once the HTTP server stopped sending them
+	defer closeGateway(gateway, grpcServer, cfg.ShutdownTimeout)
 
 	mux := http.NewServeMux()
-	mux.Handle("/", grpcMux)
+	mux.Handle("/", gateway)
+	mux.HandleFunc("/.well-known/jwks.json", server.ServeJWKS)
+	mux.HandleFunc("/.well-known/paseto-keys.json", server.ServePasetoKeys)
+	mux.Handle(metrics.Path, metrics.Handler())
+	mux.HandleFunc(health.LivenessPath, checker.ServeLiveness)
+	mux.HandleFunc(health.ReadinessPath, checker.ServeReadiness)
 
 	statikFS, err := fs.New()
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot run statik fs: %s", err))
+		return fmt.Errorf("cannot run statik fs: %w", err)
 	}
 	mux.Handle("/swagger/", http.StripPrefix("/swagger/", http.FileServer(statikFS)))
 
 	listener, err := net.Listen("tcp", cfg.HTTPServerAddress)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot create listener: %s", err))
+		return fmt.Errorf("cannot create HTTP listener: %w", err)
 	}
-	log.Printf("HTTP Gateway server listening at address %v", listener.Addr().String())
-	err = http.Serve(listener, mux)
-	if err != nil {
-		log.Fatal(fmt.Sprintf("cannot start HTTP Gateway server: %s", err))
+	// the span of each request starts from the W3C trace context of its headers, probes and scrapes aren't traced
+	handler := otelhttp.NewHandler(gapi.HttpLogger(gapi.HttpMetrics(mux)), gatewayServer,
+		otelhttp.WithFilter(isClientRequest),
+	)
+
+	// the activity streams end once the shutdown starts, it would wait for them until the timeout otherwise
+	httpServer := &http.Server{Handler: handler}
+	httpServer.RegisterOnShutdown(server.CloseAccountActivity)
+
+	log.Info().Str("address", listener.Addr().String()).Msg("HTTP Gateway server started")
+	return serveHTTP(ctx, httpServer, listener, cfg.ShutdownTimeout)
+}
+
+// stopGRPCServer stops accepting connections and waits for the calls in flight, the streams that outlive
+// the timeout are cut
+func stopGRPCServer(grpcServer *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Warn().Dur("timeout", timeout).Msg("gRPC calls still in flight, stopping the server")
+		grpcServer.Stop()
+	}
+}
+
+// closeGateway stops the in-process gRPC server of the gateway and its connection
+func closeGateway(gateway *gapi.Gateway, grpcServer *grpc.Server, timeout time.Duration) {
+	stopGRPCServer(grpcServer, timeout)
+	if err := gateway.Close(); err != nil {
+		log.Error().Err(err).Msg("cannot close gateway connection")
+	}
+}
+
+// serveHTTP serves until ctx is done, then stops accepting connections and waits up to timeout for the
+// requests in flight. The connections still open after the timeout are closed
+func serveHTTP(ctx context.Context, server *http.Server, listener net.Listener, timeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP server %s failed: %w", listener.Addr(), err)
+	case <-ctx.Done():
 	}
 
-	log.Printf("HTTP Gateway server started at address %v", listener.Addr().String())
+	log.Info().Str("address", listener.Addr().String()).Msg("shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Warn().Err(err).Dur("timeout", timeout).Msg("HTTP requests still in flight, closing the connections")
+		return server.Close()
+	}
+	log.Info().Str("address", listener.Addr().String()).Msg("HTTP server stopped")
+	return nil
+}
+
+// listenAccountActivity runs the listener of a server in the group until ctx is done, so the db is only closed
+// once it stopped. A failure is logged and the server keeps running without it
+func listenAccountActivity(ctx context.Context, group *errgroup.Group, listen func(ctx context.Context) error) {
+	group.Go(func() error {
+		if err := listen(ctx); err != nil {
+			log.Error().Err(err).Msg("cannot listen to account activity")
+		}
+		return nil
+	})
+}
+
+// isClientRequest reports whether the request comes from a client rather than from an orchestrator or Prometheus
+func isClientRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case metrics.Path, health.LivenessPath, health.ReadinessPath:
+		return false
+	}
+	return true
 }
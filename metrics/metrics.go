@@ -0,0 +1,158 @@
+// Package metrics exposes the Prometheus metrics of the servers, the store and the business events
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const namespace = "simplebank"
+
+// Path is where the servers expose the metrics
+const Path = "/metrics"
+
+// UnmatchedRoute labels HTTP requests that didn't match any route, raw paths would make the label unbounded
+const UnmatchedRoute = "unmatched"
+
+var (
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of gRPC calls by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by server, method, route and status code.",
+	}, []string{"server", "method", "route", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by server, method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "method", "route"})
+
+	txDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "tx_duration_seconds",
+		Help:      "Duration of the store transactions by name and result, retries included.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tx", "result"})
+	txRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "tx_retries_total",
+		Help:      "Store transactions run again after Postgres aborted them.",
+	}, []string{"tx"})
+
+	transfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Completed transfers by currency.",
+	}, []string{"currency"})
+	transferAmount = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transfer_amount",
+		Help:      "Amount of the completed transfers by currency.",
+		Buckets:   prometheus.ExponentialBuckets(10, 10, 7),
+	}, []string{"currency"})
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Password checks of logins by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		grpcRequests, grpcDuration,
+		httpRequests, httpDuration,
+		txDuration, txRetries,
+		transfers, transferAmount, logins,
+	)
+}
+
+// RegisterDB exposes the stats of the connection pool of conn
+func RegisterDB(conn *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(conn, namespace))
+}
+
+// Handler serves the metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveGrpcRequest records a gRPC call, code is the gRPC status code name
+func ObserveGrpcRequest(method string, code string, duration time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// ObserveHTTPRequest records a request of the server, route is the pattern the request matched
+func ObserveHTTPRequest(server string, method string, route string, statusCode int, duration time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	httpRequests.WithLabelValues(server, method, route, strconv.Itoa(statusCode)).Inc()
+	httpDuration.WithLabelValues(server, method, route).Observe(duration.Seconds())
+}
+
+// LoginSucceeded counts a login whose password was right, a second factor may still be required
+func LoginSucceeded() {
+	logins.WithLabelValues("succeeded").Inc()
+}
+
+// LoginFailed counts a login rejected for an unknown user or a wrong password
+func LoginFailed() {
+	logins.WithLabelValues("failed").Inc()
+}
+
+type routeKey struct{}
+
+// Route holds the pattern a request matched. The gateway matches the route after the middleware
+// runs, so the middleware puts a Route on the context for the gateway to fill
+type Route struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+// NewRoute returns a context carrying an empty Route
+func NewRoute(ctx context.Context) (context.Context, *Route) {
+	route := &Route{}
+	return context.WithValue(ctx, routeKey{}, route), route
+}
+
+// SetRoute sets the pattern of the Route on ctx, if any
+func SetRoute(ctx context.Context, pattern string) {
+	if route, ok := ctx.Value(routeKey{}).(*Route); ok {
+		route.mu.Lock()
+		route.pattern = pattern
+		route.mu.Unlock()
+	}
+}
+
+// Pattern returns the pattern the request matched, empty if it matched none
+func (r *Route) Pattern() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pattern
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	unmatched := httpRequests.WithLabelValues("test", http.MethodGet, UnmatchedRoute, "404")
+	before := testutil.ToFloat64(unmatched)
+
+	// requests matching no route share a label instead of their raw path
+	ObserveHTTPRequest("test", http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+	require.Equal(t, before+1, testutil.ToFloat64(unmatched))
+}
+
+func TestRoute(t *testing.T) {
+	ctx, route := NewRoute(context.Background())
+	require.Empty(t, route.Pattern())
+
+	SetRoute(ctx, "/v1/accounts/{id}")
+	require.Equal(t, "/v1/accounts/{id}", route.Pattern())
+
+	// contexts without a route are ignored
+	SetRoute(context.Background(), "/v1/accounts")
+}
+
+func TestHandler(t *testing.T) {
+	LoginFailed()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, Path, nil)
+	Handler().ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `simplebank_logins_total{result="failed"}`)
+}
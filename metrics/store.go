@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"time"
+)
+
+// store measures the transactions of the store it wraps and counts the transfers, the queries of the embedded
+// db.Querier pass through unchanged. Only the queries are embedded: a transaction added to db.Store fails the build
+// until it's measured here too
+type store struct {
+	db.Querier
+	next db.Store
+}
+
+// NewStore returns a db.Store recording the metrics of the transactions of s
+func NewStore(s db.Store) db.Store {
+	return &store{Querier: s, next: s}
+}
+
+func observeTx(tx string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	txDuration.WithLabelValues(tx, result).Observe(time.Since(start).Seconds())
+}
+
+func (s *store) TransferTx(ctx context.Context, params db.TransferTxParams) (db.TransferTxResult, error) {
+	start := time.Now()
+	result, err := s.next.TransferTx(ctx, params)
+	observeTx("TransferTx", start, err)
+
+	if result.Attempts > 1 {
+		txRetries.WithLabelValues("TransferTx").Add(float64(result.Attempts - 1))
+	}
+	if err == nil {
+		currency := result.FromAccountID.Currency
+		transfers.WithLabelValues(currency).Inc()
+		transferAmount.WithLabelValues(currency).Observe(float64(params.Amount))
+	}
+	return result, err
+}
+
+func (s *store) CreateUserTx(ctx context.Context, params db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	start := time.Now()
+	result, err := s.next.CreateUserTx(ctx, params)
+	observeTx("CreateUserTx", start, err)
+	return result, err
+}
+
+func (s *store) VerifyEmailTx(ctx context.Context, params db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+	start := time.Now()
+	result, err := s.next.VerifyEmailTx(ctx, params)
+	observeTx("VerifyEmailTx", start, err)
+	return result, err
+}
+
+func (s *store) ResetPasswordTx(ctx context.Context, params db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+	start := time.Now()
+	result, err := s.next.ResetPasswordTx(ctx, params)
+	observeTx("ResetPasswordTx", start, err)
+	return result, err
+}
+
+func (s *store) ChangePasswordTx(ctx context.Context, params db.ChangePasswordTxParams) (db.ChangePasswordTxResult, error) {
+	start := time.Now()
+	result, err := s.next.ChangePasswordTx(ctx, params)
+	observeTx("ChangePasswordTx", start, err)
+	return result, err
+}
+
+func (s *store) UpdateUserTx(ctx context.Context, params db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	start := time.Now()
+	result, err := s.next.UpdateUserTx(ctx, params)
+	observeTx("UpdateUserTx", start, err)
+	return result, err
+}
+
+func (s *store) ConfirmTotpTx(ctx context.Context, params db.ConfirmTotpTxParams) (db.ConfirmTotpTxResult, error) {
+	start := time.Now()
+	result, err := s.next.ConfirmTotpTx(ctx, params)
+	observeTx("ConfirmTotpTx", start, err)
+	return result, err
+}
+
+func (s *store) RotateSessionTx(ctx context.Context, params db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+	start := time.Now()
+	result, err := s.next.RotateSessionTx(ctx, params)
+	observeTx("RotateSessionTx", start, err)
+	return result, err
+}
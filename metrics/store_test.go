@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStoreTransferTx(t *testing.T) {
+	currency := utils.RandomCurrency()
+	params := db.TransferTxParams{
+		FromAccountID: utils.RandomInt(1, 1000),
+		ToAccountID:   utils.RandomInt(1, 1000),
+		Amount:        utils.RandomInt(1, 1000),
+	}
+
+	testCases := []struct {
+		name          string
+		result        db.TransferTxResult
+		err           error
+		transfers     float64
+		retries       float64
+		txResultLabel string
+	}{
+		{
+			name: "completed after retries",
+			result: db.TransferTxResult{
+				FromAccountID: db.Account{ID: params.FromAccountID, Currency: currency},
+				ToAccountID:   db.Account{ID: params.ToAccountID, Currency: currency},
+				Attempts:      3,
+			},
+			transfers:     1,
+			retries:       2,
+			txResultLabel: "ok",
+		},
+		{
+			name:          "failed",
+			result:        db.TransferTxResult{Attempts: 1},
+			err:           sql.ErrConnDone,
+			txResultLabel: "error",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mockdb.NewMockStore(ctrl)
+			mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(params)).Times(1).Return(tc.result, tc.err)
+
+			transfersBefore := testutil.ToFloat64(transfers.WithLabelValues(currency))
+			retriesBefore := testutil.ToFloat64(txRetries.WithLabelValues("TransferTx"))
+			durationsBefore := sampleCount(t, txDuration.WithLabelValues("TransferTx", tc.txResultLabel))
+
+			result, err := NewStore(mockStore).TransferTx(context.Background(), params)
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.result, result)
+
+			require.Equal(t, transfersBefore+tc.transfers, testutil.ToFloat64(transfers.WithLabelValues(currency)))
+			require.Equal(t, retriesBefore+tc.retries, testutil.ToFloat64(txRetries.WithLabelValues("TransferTx")))
+			require.Equal(t, durationsBefore+1, sampleCount(t, txDuration.WithLabelValues("TransferTx", tc.txResultLabel)))
+		})
+	}
+}
+
+// sampleCount returns the observations of a histogram
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
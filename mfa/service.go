@@ -0,0 +1,285 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/lockout"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"time"
+)
+
+// MaxChallengeAttempts is the number of wrong codes accepted for a login before the mfa token is discarded
+const MaxChallengeAttempts = 5
+
+var (
+	ErrAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrNotEnrolled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode      = errors.New("invalid two-factor authentication code")
+	ErrInvalidChallenge = errors.New("mfa token is invalid, already used or expired")
+)
+
+// LockedError is returned while the user can't try more codes, the wrong ones count as failed logins
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return lockout.ErrLocked.Error()
+}
+
+// Service handles TOTP enrollment and the second step of the login, it's shared by the Gin and gRPC servers
+type Service struct {
+	store             db.Store
+	lockout           *lockout.Guard
+	issuer            string
+	challengeDuration time.Duration
+}
+
+func NewService(store db.Store, guard *lockout.Guard, issuer string, challengeDuration time.Duration) *Service {
+	return &Service{
+		store:             store,
+		lockout:           guard,
+		issuer:            issuer,
+		challengeDuration: challengeDuration,
+	}
+}
+
+// Enroll stores a new unconfirmed secret for the user, replacing any previous unconfirmed one
+func (s *Service) Enroll(ctx context.Context, username string) (secret string, uri string, err error) {
+	secret, uri, err = GenerateSecret(s.issuer, username)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = s.store.UpsertTotpCredential(ctx, db.UpsertTotpCredentialParams{
+		Username: username,
+		Secret:   secret,
+	})
+	if err != nil {
+		// the upsert doesn't touch confirmed credentials
+		if err == sql.ErrNoRows {
+			return "", "", ErrAlreadyEnabled
+		}
+		return "", "", fmt.Errorf("cannot store totp secret: %w", err)
+	}
+
+	return secret, uri, nil
+}
+
+// Confirm enables 2FA once the user proves its app generates valid codes and returns the recovery codes
+func (s *Service) Confirm(ctx context.Context, username string, code string) ([]string, error) {
+	credential, err := s.store.GetTotpCredential(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotEnrolled
+		}
+		return nil, fmt.Errorf("cannot get totp secret: %w", err)
+	}
+	if credential.IsConfirmed {
+		return nil, ErrAlreadyEnabled
+	}
+
+	valid, err := s.useTotpCode(ctx, credential, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.store.ConfirmTotpTx(ctx, db.ConfirmTotpTxParams{
+		Username:           username,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot confirm totp secret: %w", err)
+	}
+
+	return codes, nil
+}
+
+// IsEnabled reports if the user has a confirmed TOTP credential
+func (s *Service) IsEnabled(ctx context.Context, username string) (bool, error) {
+	credential, err := s.store.GetTotpCredential(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot get totp secret: %w", err)
+	}
+	return credential.IsConfirmed, nil
+}
+
+// CreateChallenge returns the short-lived mfa token issued after a valid password, only its hash is stored
+func (s *Service) CreateChallenge(ctx context.Context, username string) (token string, expiresAt time.Time, err error) {
+	token, err = utils.RandomSecret(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	challenge, err := s.store.CreateMfaChallenge(ctx, db.CreateMfaChallengeParams{
+		Username:  username,
+		TokenHash: utils.HashSecret(token),
+		ExpiredAt: time.Now().Add(s.challengeDuration),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cannot create mfa challenge: %w", err)
+	}
+
+	return token, challenge.ExpiredAt, nil
+}
+
+// VerifyChallenge exchanges the mfa token and a TOTP or recovery code for the username that logged in.
+// Each token is single-use and is discarded after MaxChallengeAttempts wrong codes, the wrong codes also count
+// as failed logins of the user so they can't be guessed over many tokens
+func (s *Service) VerifyChallenge(ctx context.Context, token string, code string) (string, error) {
+	challenge, err := s.store.GetMfaChallenge(ctx, utils.HashSecret(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrInvalidChallenge
+		}
+		return "", fmt.Errorf("cannot get mfa challenge: %w", err)
+	}
+	if challenge.IsUsed || challenge.Attempts >= MaxChallengeAttempts || time.Now().After(challenge.ExpiredAt) {
+		return "", ErrInvalidChallenge
+	}
+
+	credential, err := s.getCredential(ctx, challenge.Username)
+	if err != nil {
+		return "", err
+	}
+	if err = s.attempt(ctx, challenge.Username); err != nil {
+		return "", err
+	}
+
+	valid, err := s.checkCode(ctx, credential, code, true)
+	if err != nil {
+		return "", err
+	}
+	if !valid {
+		if _, err = s.store.IncrementMfaChallengeAttempts(ctx, challenge.ID); err != nil {
+			return "", fmt.Errorf("cannot update mfa challenge: %w", err)
+		}
+		return "", s.failed(ctx, challenge.Username)
+	}
+
+	// concurrent requests with the same token can't both succeed
+	if _, err = s.store.UseMfaChallenge(ctx, challenge.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrInvalidChallenge
+		}
+		return "", fmt.Errorf("cannot update mfa challenge: %w", err)
+	}
+
+	if err = s.lockout.RecordSuccess(ctx, challenge.Username, ""); err != nil {
+		return "", err
+	}
+	return challenge.Username, nil
+}
+
+// VerifyCode checks a TOTP code of a user with 2FA enabled, it's used to authorize sensitive operations.
+// Wrong codes count as failed logins of the user, and each code is only accepted once
+func (s *Service) VerifyCode(ctx context.Context, username string, code string) error {
+	credential, err := s.getCredential(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err = s.attempt(ctx, username); err != nil {
+		return err
+	}
+
+	valid, err := s.checkCode(ctx, credential, code, false)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return s.failed(ctx, username)
+	}
+	return s.lockout.RecordSuccess(ctx, username, "")
+}
+
+// getCredential returns the confirmed TOTP credential of the user, or ErrNotEnrolled
+func (s *Service) getCredential(ctx context.Context, username string) (db.TotpCredential, error) {
+	credential, err := s.store.GetTotpCredential(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.TotpCredential{}, ErrNotEnrolled
+		}
+		return db.TotpCredential{}, fmt.Errorf("cannot get totp secret: %w", err)
+	}
+	if !credential.IsConfirmed {
+		return db.TotpCredential{}, ErrNotEnrolled
+	}
+	return credential, nil
+}
+
+// attempt counts the code attempt in the login lockout of the user
+func (s *Service) attempt(ctx context.Context, username string) error {
+	retryAfter, err := s.lockout.Attempt(ctx, username, "")
+	if err != nil {
+		if err == lockout.ErrLocked {
+			return &LockedError{RetryAfter: retryAfter}
+		}
+		return err
+	}
+	return nil
+}
+
+// failed delays the next attempt of the user and returns ErrInvalidCode
+func (s *Service) failed(ctx context.Context, username string) error {
+	if err := s.lockout.RecordFailure(ctx, username); err != nil {
+		return err
+	}
+	return ErrInvalidCode
+}
+
+func (s *Service) checkCode(ctx context.Context, credential db.TotpCredential, code string, allowRecoveryCode bool) (bool, error) {
+	if isTotpCode(code) {
+		return s.useTotpCode(ctx, credential, code)
+	}
+	if !allowRecoveryCode {
+		return false, nil
+	}
+
+	_, err := s.store.UseMfaRecoveryCode(ctx, db.UseMfaRecoveryCodeParams{
+		Username: credential.Username,
+		CodeHash: HashRecoveryCode(code),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot use recovery code: %w", err)
+	}
+	return true, nil
+}
+
+// useTotpCode checks the code and stores its time step, a code of the same or an earlier step was already used
+// and is rejected, so a code seen by someone else can't be replayed within its period
+func (s *Service) useTotpCode(ctx context.Context, credential db.TotpCredential, code string) (bool, error) {
+	step, valid := MatchCode(code, credential.Secret, time.Now())
+	if !valid || step <= credential.LastUsedStep {
+		return false, nil
+	}
+
+	_, err := s.store.UseTotpStep(ctx, db.UseTotpStepParams{
+		Step:     step,
+		Username: credential.Username,
+	})
+	if err != nil {
+		// a concurrent request used the code first
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot use totp code: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package mfa
+
+import (
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpPeriod is the number of seconds each code is valid for, the default of the authenticator apps
+	totpPeriod = 30
+)
+
+var isTotpCode = regexp.MustCompile("^[0-9]{6}$").MatchString
+
+// GenerateSecret creates a TOTP secret and the otpauth URI authenticator apps read from a QR code
+func GenerateSecret(issuer string, username string) (secret string, uri string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: username,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("cannot generate totp secret: %w", err)
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateCode checks a 6 digit code against the secret, allowing one period of clock skew
+func ValidateCode(code string, secret string) bool {
+	_, valid := MatchCode(code, secret, time.Now())
+	return valid
+}
+
+// MatchCode returns the time step the code was generated for when it's valid for the secret at now, allowing one
+// period of clock skew. The steps only grow, each code is accepted once by storing the last step used
+func MatchCode(code string, secret string, now time.Time) (int64, bool) {
+	if !isTotpCode(code) {
+		return 0, false
+	}
+
+	for skew := -1; skew <= 1; skew++ {
+		at := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		valid, err := totp.ValidateCustom(code, secret, at, totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && valid {
+			return at.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns the codes shown once to the user and their hashes to store
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		secret, err := utils.RandomSecret(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := secret[:5] + "-" + secret[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalizes the code as users may type it before hashing it
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return utils.HashSecret(code)
+}
@@ -0,0 +1,69 @@
+package mfa
+
+import (
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateCode(t *testing.T) {
+	secret, uri, err := GenerateSecret("SimpleBank", "alice")
+	require.NoError(t, err)
+	require.Contains(t, uri, "otpauth://totp/SimpleBank:alice")
+	require.Contains(t, uri, secret)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	require.True(t, ValidateCode(code, secret))
+
+	// codes of other secrets or malformed codes are rejected
+	otherSecret, _, err := GenerateSecret("SimpleBank", "bob")
+	require.NoError(t, err)
+	require.False(t, ValidateCode(code, otherSecret))
+	require.False(t, ValidateCode("12345", secret))
+	require.False(t, ValidateCode("", secret))
+}
+
+func TestMatchCode(t *testing.T) {
+	secret, _, err := GenerateSecret("SimpleBank", "alice")
+	require.NoError(t, err)
+
+	now := time.Unix(1700000010, 0)
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step, valid := MatchCode(code, secret, now)
+	require.True(t, valid)
+	require.Equal(t, now.Unix()/30, step)
+
+	// the code of the previous period is still accepted for its own step
+	step, valid = MatchCode(code, secret, now.Add(30*time.Second))
+	require.True(t, valid)
+	require.Equal(t, now.Unix()/30, step)
+
+	_, valid = MatchCode(code, secret, now.Add(time.Minute))
+	require.False(t, valid)
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		require.Len(t, code, 11)
+		require.Equal(t, hashes[i], HashRecoveryCode(code))
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	hash := HashRecoveryCode("abcde-12345")
+	require.Equal(t, hash, HashRecoveryCode(" ABCDE-12345 "))
+	require.Equal(t, hash, HashRecoveryCode("abcde12345"))
+	require.NotEqual(t, hash, HashRecoveryCode("abcde-12346"))
+}
@@ -0,0 +1,30 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method supported, plain challenges would leak the verifier
+const CodeChallengeMethodS256 = "S256"
+
+// RFC 7636 limits of the code verifier
+const (
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// CodeChallenge returns the S256 challenge of the verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge reports if the verifier sent to the token endpoint matches the challenge of the authorization request
+func VerifyCodeChallenge(codeVerifier string, codeChallenge string) bool {
+	if len(codeVerifier) < minCodeVerifierLength || len(codeVerifier) > maxCodeVerifierLength {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CodeChallenge(codeVerifier)), []byte(codeChallenge)) == 1
+}
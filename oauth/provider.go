@@ -0,0 +1,412 @@
+// Package oauth lets third-party apps reach the resources of users with the authorization code grant, protected
+// with PKCE, and the resources of the user that registered them with the client credentials grant
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	ResponseTypeCode           = "code"
+	TokenTypeBearer            = "Bearer"
+)
+
+// error codes of RFC 6749
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+)
+
+var ErrGrantRevoked = errors.New("the access of the app was revoked")
+
+// Error is an error response of RFC 6749, Code is sent to the app in the error field
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func newError(code string, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// AuthorizationRequest is what an app asks the user to approve
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenResponse is the response of the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// Provider registers apps, records the consent of users and issues the access tokens of the apps with the
+// token maker of the server, so they are verified like any other token
+type Provider struct {
+	store         db.Store
+	tokenMaker    token.Maker
+	codeDuration  time.Duration
+	tokenDuration time.Duration
+}
+
+// NewProvider reads the lifetime of codes and tokens from the OAUTH_* settings
+func NewProvider(store db.Store, tokenMaker token.Maker, config utils.Config) *Provider {
+	return &Provider{
+		store:         store,
+		tokenMaker:    tokenMaker,
+		codeDuration:  config.OAuthCodeDuration,
+		tokenDuration: config.OAuthTokenDuration,
+	}
+}
+
+// RegisterClient registers an app of the owner. Confidential apps get a secret, which is only returned here,
+// while public apps like mobile or single page apps can only use the authorization code grant
+func (p *Provider) RegisterClient(ctx context.Context, owner string, name string, redirectURIs []string, scopes []string, confidential bool) (db.OauthClient, string, error) {
+	clientID, err := utils.RandomSecret(16)
+	if err != nil {
+		return db.OauthClient{}, "", err
+	}
+
+	var secret, hashedSecret string
+	if confidential {
+		secret, err = utils.RandomSecret(32)
+		if err != nil {
+			return db.OauthClient{}, "", err
+		}
+		hashedSecret = utils.HashSecret(secret)
+	}
+
+	client, err := p.store.CreateOauthClient(ctx, db.CreateOauthClientParams{
+		ClientID:     clientID,
+		HashedSecret: hashedSecret,
+		Name:         name,
+		Owner:        owner,
+		RedirectUris: redirectURIs,
+		Scopes:       scopes,
+	})
+	if err != nil {
+		return db.OauthClient{}, "", fmt.Errorf("cannot create oauth client: %w", err)
+	}
+
+	return client, secret, nil
+}
+
+// ValidateClient returns the app of the request if the redirect URI is one of its own. Its errors must not
+// be sent to the redirect URI, since it can't be trusted yet
+func (p *Provider) ValidateClient(ctx context.Context, clientID string, redirectURI string) (db.OauthClient, error) {
+	client, err := p.getClient(ctx, clientID)
+	if err != nil {
+		return db.OauthClient{}, err
+	}
+
+	if !contains(client.RedirectUris, redirectURI) {
+		return db.OauthClient{}, newError(ErrorInvalidRequest, "redirect_uri is not registered for the client")
+	}
+	// apps registered before the redirect URIs were validated can't redirect to a script or a custom scheme
+	if err = ValidateRedirectURI(redirectURI); err != nil {
+		return db.OauthClient{}, newError(ErrorInvalidRequest, err.Error())
+	}
+
+	return client, nil
+}
+
+// ValidateRedirectURI accepts absolute https URIs, and http ones on a loopback host for native apps (RFC 8252).
+// The front-end sends the user to the URI, any other scheme could run a script or hand the code to another app
+func ValidateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("invalid redirect_uri: %w", err)
+	}
+
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && isLoopback(u.Hostname()):
+	default:
+		return fmt.Errorf("redirect_uri must use https, or http on a loopback host")
+	}
+	if u.Host == "" || u.User != nil {
+		return fmt.Errorf("redirect_uri must be an absolute URI without credentials")
+	}
+	// the code is added to the query, RFC 6749 forbids fragments
+	if u.Fragment != "" || strings.Contains(redirectURI, "#") {
+		return fmt.Errorf("redirect_uri must not have a fragment")
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ValidateAuthorization checks the rest of the request, its errors are sent to the app with RedirectError
+func (p *Provider) ValidateAuthorization(client db.OauthClient, req AuthorizationRequest) error {
+	if req.ResponseType != ResponseTypeCode {
+		return newError(ErrorUnsupportedResponseType, "only the code response type is supported")
+	}
+
+	if err := checkScopes(client, req.Scopes); err != nil {
+		return err
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return newError(ErrorInvalidRequest, "a code_challenge with the S256 method is required")
+	}
+
+	return nil
+}
+
+// Approve records the consent of the user and returns the redirect URI with a single-use authorization code
+func (p *Provider) Approve(ctx context.Context, username string, req AuthorizationRequest) (string, error) {
+	_, err := p.store.UpsertOauthConsent(ctx, db.UpsertOauthConsentParams{
+		Username: username,
+		ClientID: req.ClientID,
+		Scopes:   req.Scopes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot record consent: %w", err)
+	}
+
+	code, err := utils.RandomSecret(32)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = p.store.CreateOauthAuthorizationCode(ctx, db.CreateOauthAuthorizationCodeParams{
+		HashedCode:    utils.HashSecret(code),
+		ClientID:      req.ClientID,
+		Username:      username,
+		RedirectUri:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(p.codeDuration),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create authorization code: %w", err)
+	}
+
+	return redirectURL(req, map[string]string{"code": code})
+}
+
+// RedirectError returns the redirect URI that reports the error to the app
+func RedirectError(req AuthorizationRequest, err *Error) (string, error) {
+	return redirectURL(req, map[string]string{
+		"error":             err.Code,
+		"error_description": err.Description,
+	})
+}
+
+// ExchangeCode issues the access token of an authorization code, the code can only be used once
+func (p *Provider) ExchangeCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string) (*TokenResponse, error) {
+	client, err := p.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationCode, err := p.store.UseOauthAuthorizationCode(ctx, utils.HashSecret(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newError(ErrorInvalidGrant, "code is invalid or was already used")
+		}
+		return nil, fmt.Errorf("cannot use authorization code: %w", err)
+	}
+
+	if authorizationCode.ClientID != client.ClientID {
+		return nil, newError(ErrorInvalidGrant, "code was issued to another client")
+	}
+
+	if time.Now().After(authorizationCode.ExpiresAt) {
+		return nil, newError(ErrorInvalidGrant, "code has expired")
+	}
+
+	if authorizationCode.RedirectUri != redirectURI {
+		return nil, newError(ErrorInvalidGrant, "redirect_uri doesn't match the authorization request")
+	}
+
+	if !VerifyCodeChallenge(codeVerifier, authorizationCode.CodeChallenge) {
+		return nil, newError(ErrorInvalidGrant, "code_verifier doesn't match the code_challenge")
+	}
+
+	return p.issueToken(authorizationCode.Username, client.ClientID, authorizationCode.Scopes)
+}
+
+// ClientCredentials issues a token that acts as the owner of a confidential app, limited to the scopes of the app.
+// When no scopes are requested every scope of the app is granted
+func (p *Provider) ClientCredentials(ctx context.Context, clientID string, clientSecret string, scopes []string) (*TokenResponse, error) {
+	client, err := p.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.HashedSecret == "" {
+		return nil, newError(ErrorUnauthorizedClient, "public clients can't use the client_credentials grant")
+	}
+
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if err = checkScopes(client, scopes); err != nil {
+		return nil, err
+	}
+
+	return p.issueToken(client.Owner, client.ClientID, scopes)
+}
+
+// CheckGrant returns ErrGrantRevoked when the token of an app is used after the user revoked its consent.
+// Approving the app again restarts the consent, the tokens issued before it stay revoked.
+// Tokens of the client credentials grant act as the owner of the app and have no consent of their own
+func (p *Provider) CheckGrant(ctx context.Context, payload *token.Payload) error {
+	if payload.Audience == "" {
+		return nil
+	}
+
+	consent, err := p.store.GetOauthConsent(ctx, db.GetOauthConsentParams{
+		Username: payload.UserName,
+		ClientID: payload.Audience,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("cannot get consent: %w", err)
+	}
+	if err == nil && !consent.RevokedAt.Valid && !payload.IssuedAt.Before(consent.CreatedAt) {
+		return nil
+	}
+
+	client, err := p.store.GetOauthClient(ctx, payload.Audience)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrGrantRevoked
+		}
+		return fmt.Errorf("cannot get oauth client: %w", err)
+	}
+
+	if client.Owner != payload.UserName {
+		return ErrGrantRevoked
+	}
+
+	// the owner revoked its own consent, which also revokes the tokens issued before
+	if consent.RevokedAt.Valid && consent.RevokedAt.Time.After(payload.IssuedAt) {
+		return ErrGrantRevoked
+	}
+
+	return nil
+}
+
+func (p *Provider) getClient(ctx context.Context, clientID string) (db.OauthClient, error) {
+	client, err := p.store.GetOauthClient(ctx, clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.OauthClient{}, newError(ErrorInvalidClient, "client is not registered")
+		}
+		return db.OauthClient{}, fmt.Errorf("cannot get oauth client: %w", err)
+	}
+	return client, nil
+}
+
+// authenticateClient checks the secret of confidential apps, public apps must not send one
+func (p *Provider) authenticateClient(ctx context.Context, clientID string, clientSecret string) (db.OauthClient, error) {
+	client, err := p.getClient(ctx, clientID)
+	if err != nil {
+		return db.OauthClient{}, err
+	}
+
+	if client.HashedSecret == "" {
+		if clientSecret != "" {
+			return db.OauthClient{}, newError(ErrorInvalidClient, "public clients don't have a secret")
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.HashedSecret), []byte(utils.HashSecret(clientSecret))) != 1 {
+		return db.OauthClient{}, newError(ErrorInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+func (p *Provider) issueToken(username string, clientID string, scopes []string) (*TokenResponse, error) {
+	accessToken, payload, err := p.tokenMaker.CreateScopedToken(username, clientID, scopes, p.tokenDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error creating access token: %s", err)
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(time.Until(payload.ExpiredAt).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// checkScopes returns invalid_scope unless the app was registered with every scope
+func checkScopes(client db.OauthClient, scopes []string) error {
+	if len(scopes) == 0 {
+		return newError(ErrorInvalidScope, "scope is required")
+	}
+
+	for _, scope := range scopes {
+		if !contains(client.Scopes, scope) {
+			return newError(ErrorInvalidScope, fmt.Sprintf("client is not allowed the %s scope", scope))
+		}
+	}
+	return nil
+}
+
+func redirectURL(req AuthorizationRequest, params map[string]string) (string, error) {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect_uri: %w", err)
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Package policy decides what an authenticated user can do, both from its role and from the resources it owns
+package policy
+
+import (
+	"errors"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+)
+
+type Action string
+
+const (
+	ViewAccount         Action = "account:view"
+	DeleteAccount       Action = "account:delete"
+	TransferFromAccount Action = "account:transfer"
+	FreezeAccount       Action = "account:freeze"
+	ViewUser            Action = "user:view"
+	UpdateUser          Action = "user:update"
+	ManageUsers         Action = "users:manage"
+	ManageApiKeys       Action = "api_keys:manage"
+)
+
+var ErrForbidden = errors.New("the authenticated user is not allowed to perform this operation")
+
+// ownerActions are allowed to every user on the resources it owns
+var ownerActions = map[Action]bool{
+	ViewAccount:         true,
+	DeleteAccount:       true,
+	TransferFromAccount: true,
+	ViewUser:            true,
+	UpdateUser:          true,
+	ManageApiKeys:       true,
+}
+
+// roleActions are allowed on every resource regardless of its owner
+var roleActions = map[string]map[Action]bool{
+	utils.BankerRole: {
+		ViewAccount: true,
+	},
+	utils.AdminRole: {
+		ViewAccount:   true,
+		FreezeAccount: true,
+		ViewUser:      true,
+		UpdateUser:    true,
+		ManageUsers:   true,
+		ManageApiKeys: true,
+	},
+}
+
+// Allowed reports if the role grants the action on every resource
+func Allowed(role string, action Action) bool {
+	return roleActions[role][action]
+}
+
+// Authorize returns ErrForbidden unless the role of the token grants the action or the user owns the resource
+func Authorize(payload *token.Payload, action Action, owner string) error {
+	if Allowed(payload.Role, action) {
+		return nil
+	}
+
+	if owner != "" && owner == payload.UserName && ownerActions[action] {
+		return nil
+	}
+
+	return ErrForbidden
+}
@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	owner := utils.RandomOwner()
+	other := utils.RandomOwner()
+
+	testCases := []struct {
+		name    string
+		payload *token.Payload
+		action  Action
+		owner   string
+		allowed bool
+	}{
+		{
+			name:    "owner views its account",
+			payload: &token.Payload{UserName: owner, Role: utils.CustomerRole},
+			action:  ViewAccount,
+			owner:   owner,
+			allowed: true,
+		},
+		{
+			name:    "customer views another account",
+			payload: &token.Payload{UserName: other, Role: utils.CustomerRole},
+			action:  ViewAccount,
+			owner:   owner,
+			allowed: false,
+		},
+		{
+			name:    "banker views any account",
+			payload: &token.Payload{UserName: other, Role: utils.BankerRole},
+			action:  ViewAccount,
+			owner:   owner,
+			allowed: true,
+		},
+		{
+			name:    "banker can't transfer from another account",
+			payload: &token.Payload{UserName: other, Role: utils.BankerRole},
+			action:  TransferFromAccount,
+			owner:   owner,
+			allowed: false,
+		},
+		{
+			name:    "customer updates another user",
+			payload: &token.Payload{UserName: other, Role: utils.CustomerRole},
+			action:  UpdateUser,
+			owner:   owner,
+			allowed: false,
+		},
+		{
+			name:    "admin updates any user",
+			payload: &token.Payload{UserName: other, Role: utils.AdminRole},
+			action:  UpdateUser,
+			owner:   owner,
+			allowed: true,
+		},
+		{
+			name:    "admin freezes any account",
+			payload: &token.Payload{UserName: other, Role: utils.AdminRole},
+			action:  FreezeAccount,
+			owner:   owner,
+			allowed: true,
+		},
+		{
+			name:    "owner can't freeze its account",
+			payload: &token.Payload{UserName: owner, Role: utils.CustomerRole},
+			action:  FreezeAccount,
+			owner:   owner,
+			allowed: false,
+		},
+		{
+			name:    "admin manages users",
+			payload: &token.Payload{UserName: other, Role: utils.AdminRole},
+			action:  ManageUsers,
+			allowed: true,
+		},
+		{
+			name:    "token without role",
+			payload: &token.Payload{UserName: other},
+			action:  ManageUsers,
+			allowed: false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := Authorize(tc.payload, tc.action, tc.owner)
+			if tc.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrForbidden)
+			}
+		})
+	}
+}
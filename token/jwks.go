@@ -0,0 +1,102 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const paserkV4PublicPrefix = "k4.public."
+
+var ErrUnsupportedKey = errors.New("unsupported public key")
+
+// JWK is an Ed25519 public key as described in RFC 8037
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PaserkKey is a public key in the k4.public PASERK format
+type PaserkKey struct {
+	KeyID string `json:"kid"`
+	Key   string `json:"key"`
+}
+
+// PaserkSet is the PASETO equivalent of the JWKSet
+type PaserkSet struct {
+	Keys []PaserkKey `json:"keys"`
+}
+
+// PublicKey returns the Ed25519 key of the JWK
+func (k JWK) PublicKey() (ed25519.PublicKey, error) {
+	if k.KeyType != "OKP" || k.Curve != "Ed25519" {
+		return nil, ErrUnsupportedKey
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
+
+// PublicKey returns the Ed25519 key of the PASERK
+func (k PaserkKey) PublicKey() (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(k.Key, paserkV4PublicPrefix) {
+		return nil, ErrUnsupportedKey
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(k.Key, paserkV4PublicPrefix))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
+
+// JWKS returns every verification key of the ring sorted by kid
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range r.keyIDs() {
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(r.publicKeys[kid]),
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+	return set
+}
+
+// PaserkKeys returns every verification key of the ring sorted by kid
+func (r *KeyRing) PaserkKeys() PaserkSet {
+	set := PaserkSet{Keys: []PaserkKey{}}
+	for _, kid := range r.keyIDs() {
+		set.Keys = append(set.Keys, PaserkKey{
+			KeyID: kid,
+			Key:   paserkV4PublicPrefix + base64.RawURLEncoding.EncodeToString(r.publicKeys[kid]),
+		})
+	}
+	return set
+}
+
+func (r *KeyRing) keyIDs() []string {
+	kids := make([]string, 0, len(r.publicKeys))
+	for kid := range r.publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
@@ -21,12 +21,31 @@ func NewJWTMaker(secreykey string) (Maker, error) {
 	return &JWTMaker{secreykey}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", nil, err
 	}
+	return maker.signToken(payload)
+}
+
+func (maker *JWTMaker) CreateRefreshToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewRefreshPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *JWTMaker) CreateScopedToken(username string, audience string, scopes []string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(username, audience, scopes, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
 
+func (maker *JWTMaker) signToken(payload *Payload) (string, *Payload, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	signedToken, _ := jwtToken.SignedString([]byte(maker.secretKey))
 	return signedToken, payload, nil
@@ -0,0 +1,87 @@
+package token
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"time"
+)
+
+// JWTPublicMaker signs EdDSA JWTs with the active key of the ring, the kid goes in the header
+type JWTPublicMaker struct {
+	keys *KeyRing
+}
+
+func NewJWTPublicMaker(keys *KeyRing) (Maker, error) {
+	return &JWTPublicMaker{keys: keys}, nil
+}
+
+func (maker *JWTPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *JWTPublicMaker) CreateRefreshToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewRefreshPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *JWTPublicMaker) CreateScopedToken(username string, audience string, scopes []string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(username, audience, scopes, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *JWTPublicMaker) signToken(payload *Payload) (string, *Payload, error) {
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, payload)
+	jwtToken.Header["kid"] = maker.keys.activeKeyID
+
+	signedToken, err := jwtToken.SignedString(maker.keys.signingKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signedToken, payload, nil
+}
+
+func (maker *JWTPublicMaker) VerifyToken(token string) (*Payload, error) {
+	return VerifyJWTPublicToken(token, maker.keys)
+}
+
+// KeyRing returns the keys the tokens are signed with
+func (maker *JWTPublicMaker) KeyRing() *KeyRing {
+	return maker.keys
+}
+
+// VerifyJWTPublicToken verifies an EdDSA JWT with the key of the kid in its header
+func VerifyJWTPublicToken(token string, keys PublicKeySource) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*jwt.SigningMethodEd25519)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		return keys.PublicKey(kid)
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
@@ -0,0 +1,66 @@
+package token
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestJWTPublicMaker(t *testing.T) {
+	maker, err := NewJWTPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	username := utils.RandomOwner()
+	duration := time.Minute
+	issuedAt := time.Now()
+	expiredAt := time.Now().Add(duration)
+
+	token, payload, err := maker.CreateToken(username, utils.CustomerRole, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.UserName)
+	require.Equal(t, utils.CustomerRole, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestJWTPublicExpiredToken(t *testing.T) {
+	maker, err := NewJWTPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(utils.RandomString(32), utils.CustomerRole, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTPublicInvalidToken(t *testing.T) {
+	payload, err := NewPayload(utils.RandomString(32), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// an HS256 token signed with a guessed secret must not pass as EdDSA
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken.Header["kid"] = "key-1"
+	token, err := jwtToken.SignedString([]byte(utils.RandomString(32)))
+	require.NoError(t, err)
+
+	maker, err := NewJWTPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
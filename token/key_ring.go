@@ -0,0 +1,126 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrUnknownKeyID      = errors.New("unknown token key id")
+	ErrMissingSigningKey = errors.New("active token key has no private key")
+)
+
+// KeyRing holds the Ed25519 keys used for asymmetric tokens. Tokens are signed with the active key and verified with
+// the key matching their kid, so older keys keep verifying the tokens they signed until they are removed from the ring
+type KeyRing struct {
+	activeKeyID string
+	signingKey  ed25519.PrivateKey
+	publicKeys  map[string]ed25519.PublicKey
+}
+
+func NewKeyRing(activeKeyID string, signingKey ed25519.PrivateKey, publicKeys map[string]ed25519.PublicKey) (*KeyRing, error) {
+	if len(signingKey) != ed25519.PrivateKeySize {
+		return nil, ErrMissingSigningKey
+	}
+
+	ring := &KeyRing{
+		activeKeyID: activeKeyID,
+		signingKey:  signingKey,
+		publicKeys:  map[string]ed25519.PublicKey{activeKeyID: signingKey.Public().(ed25519.PublicKey)},
+	}
+	for kid, key := range publicKeys {
+		if kid == activeKeyID {
+			continue
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: %w", kid, ErrInvalidKeySize)
+		}
+		ring.publicKeys[kid] = key
+	}
+
+	return ring, nil
+}
+
+// LoadKeyRing reads PEM encoded Ed25519 keys, the kid of each key is its file name without the extension.
+// The active key must be a PKCS #8 private key, the rest can be either private or PKIX public keys
+func LoadKeyRing(activeKeyID string, keyFiles []string) (*KeyRing, error) {
+	var signingKey ed25519.PrivateKey
+	publicKeys := make(map[string]ed25519.PublicKey, len(keyFiles))
+
+	for _, file := range keyFiles {
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read token key %s: %w", kid, err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("token key %s is not PEM encoded", kid)
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse token key %s: %w", kid, err)
+			}
+			privateKey, ok := key.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("token key %s is not an ed25519 key", kid)
+			}
+			if kid == activeKeyID {
+				signingKey = privateKey
+			}
+			publicKeys[kid] = privateKey.Public().(ed25519.PublicKey)
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse token key %s: %w", kid, err)
+			}
+			publicKey, ok := key.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("token key %s is not an ed25519 key", kid)
+			}
+			publicKeys[kid] = publicKey
+		default:
+			return nil, fmt.Errorf("token key %s has unsupported PEM type %q", kid, block.Type)
+		}
+	}
+
+	if signingKey == nil {
+		return nil, fmt.Errorf("key %s: %w", activeKeyID, ErrMissingSigningKey)
+	}
+
+	return NewKeyRing(activeKeyID, signingKey, publicKeys)
+}
+
+// ActiveKeyID returns the kid of the key new tokens are signed with
+func (r *KeyRing) ActiveKeyID() string {
+	return r.activeKeyID
+}
+
+// PublicKey returns the verification key of kid
+func (r *KeyRing) PublicKey(kid string) (ed25519.PublicKey, error) {
+	key, ok := r.publicKeys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// PublicKeys returns a copy of every verification key by kid
+func (r *KeyRing) PublicKeys() map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey, len(r.publicKeys))
+	for kid, key := range r.publicKeys {
+		keys[kid] = key
+	}
+	return keys
+}
@@ -0,0 +1,108 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func randomKeyRing(t *testing.T, kid string) *KeyRing {
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	ring, err := NewKeyRing(kid, signingKey, nil)
+	require.NoError(t, err)
+	return ring
+}
+
+func writeKeyFile(t *testing.T, dir, kid string, key interface{}) string {
+	var block *pem.Block
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	case ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	file := filepath.Join(dir, kid+".pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(block), 0o600))
+	return file
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	oldPublicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	activePublicKey, activePrivateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	files := []string{
+		writeKeyFile(t, dir, "2023-12", oldPublicKey),
+		writeKeyFile(t, dir, "2024-01", activePrivateKey),
+	}
+
+	ring, err := LoadKeyRing("2024-01", files)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01", ring.ActiveKeyID())
+
+	key, err := ring.PublicKey("2024-01")
+	require.NoError(t, err)
+	require.Equal(t, activePublicKey, key)
+
+	key, err = ring.PublicKey("2023-12")
+	require.NoError(t, err)
+	require.Equal(t, oldPublicKey, key)
+
+	require.Len(t, ring.PublicKeys(), 2)
+
+	_, err = ring.PublicKey("2023-11")
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestLoadKeyRingWithoutSigningKey(t *testing.T) {
+	dir := t.TempDir()
+
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	// a public key can't sign new tokens
+	files := []string{writeKeyFile(t, dir, "2024-01", publicKey)}
+
+	_, err = LoadKeyRing("2024-01", files)
+	require.ErrorIs(t, err, ErrMissingSigningKey)
+
+	_, err = LoadKeyRing("2024-02", files)
+	require.ErrorIs(t, err, ErrMissingSigningKey)
+}
+
+func TestKeyRingPublishedKeys(t *testing.T) {
+	ring := randomKeyRing(t, "key-1")
+	expected, err := ring.PublicKey("key-1")
+	require.NoError(t, err)
+
+	jwks := ring.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "key-1", jwks.Keys[0].KeyID)
+	require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+
+	key, err := jwks.Keys[0].PublicKey()
+	require.NoError(t, err)
+	require.Equal(t, expected, key)
+
+	paserks := ring.PaserkKeys()
+	require.Len(t, paserks.Keys, 1)
+	require.Equal(t, "key-1", paserks.Keys[0].KeyID)
+
+	key, err = paserks.Keys[0].PublicKey()
+	require.NoError(t, err)
+	require.Equal(t, expected, key)
+}
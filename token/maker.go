@@ -1,8 +1,61 @@
 package token
 
-import "time"
+import (
+	"crypto/ed25519"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"time"
+)
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates an access token
+	CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
+	// CreateRefreshToken creates a token that is only accepted to renew the access token
+	CreateRefreshToken(username string, role string, duration time.Duration) (string, *Payload, error)
+	// CreateScopedToken creates a token for the audience that only grants the scopes
+	CreateScopedToken(username string, audience string, scopes []string, duration time.Duration) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
+
+// PublicMaker signs tokens that anyone holding the public keys of its ring can verify
+type PublicMaker interface {
+	Maker
+	KeyRing() *KeyRing
+}
+
+// PublicKeySource looks up the verification key of a kid
+type PublicKeySource interface {
+	PublicKey(kid string) (ed25519.PublicKey, error)
+}
+
+// The formats of the tokens signed with the key files, set with TOKEN_TYPE
+const (
+	TypePaseto = "paseto"
+	TypeJWT    = "jwt"
+)
+
+// NewMaker returns a PASETO v4.public maker, or an EdDSA JWT maker with TOKEN_TYPE=jwt, when token key files are
+// configured, otherwise it falls back to the v2.local maker with the symmetric key
+func NewMaker(config utils.Config) (Maker, error) {
+	newPublicMaker := NewPasetoPublicMaker
+	switch config.TokenType {
+	case "", TypePaseto:
+	case TypeJWT:
+		newPublicMaker = NewJWTPublicMaker
+	default:
+		return nil, fmt.Errorf("unknown token type %q", config.TokenType)
+	}
+
+	if len(config.TokenKeyFiles) == 0 {
+		if config.TokenType == TypeJWT {
+			return nil, fmt.Errorf("%s tokens need TOKEN_KEY_FILES", TypeJWT)
+		}
+		return NewPasetoMaker(config.TokenSymmetricKey)
+	}
+
+	keys, err := LoadKeyRing(config.TokenActiveKeyID, config.TokenKeyFiles)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load token keys: %w", err)
+	}
+	return newPublicMaker(keys)
+}
@@ -0,0 +1,104 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewMaker(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	keyFiles := []string{writeKeyFile(t, t.TempDir(), "key-1", privateKey)}
+
+	testCases := []struct {
+		name       string
+		config     utils.Config
+		checkMaker func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name:   "symmetric key",
+			config: utils.Config{TokenSymmetricKey: utils.RandomString(32)},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:   "paseto key files",
+			config: utils.Config{TokenType: TypePaseto, TokenActiveKeyID: "key-1", TokenKeyFiles: keyFiles},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoPublicMaker{}, maker)
+			},
+		},
+		{
+			name:   "jwt key files",
+			config: utils.Config{TokenType: TypeJWT, TokenActiveKeyID: "key-1", TokenKeyFiles: keyFiles},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTPublicMaker{}, maker)
+			},
+		},
+		{
+			// JWTs are only signed with the key files
+			name:   "jwt without key files",
+			config: utils.Config{TokenType: TypeJWT, TokenSymmetricKey: utils.RandomString(32)},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "unknown type",
+			config: utils.Config{TokenType: "macaroon", TokenSymmetricKey: utils.RandomString(32)},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, `unknown token type "macaroon"`)
+				require.Nil(t, maker)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.config)
+			tc.checkMaker(t, maker, err)
+		})
+	}
+}
+
+// TestTokenType checks every maker signs the type of the token, so a refresh token can't be used as an access token
+func TestTokenType(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	keys, err := LoadKeyRing("key-1", []string{writeKeyFile(t, t.TempDir(), "key-1", privateKey)})
+	require.NoError(t, err)
+
+	pasetoMaker, err := NewPasetoMaker(utils.RandomString(32))
+	require.NoError(t, err)
+	jwtMaker, err := NewJWTMaker(utils.RandomString(32))
+	require.NoError(t, err)
+	pasetoPublicMaker, err := NewPasetoPublicMaker(keys)
+	require.NoError(t, err)
+	jwtPublicMaker, err := NewJWTPublicMaker(keys)
+	require.NoError(t, err)
+
+	for _, maker := range []Maker{pasetoMaker, jwtMaker, pasetoPublicMaker, jwtPublicMaker} {
+		accessToken, _, err := maker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+		require.NoError(t, err)
+		payload, err := maker.VerifyToken(accessToken)
+		require.NoError(t, err)
+		require.NoError(t, payload.CheckType(AccessToken))
+
+		refreshToken, _, err := maker.CreateRefreshToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+		require.NoError(t, err)
+		payload, err = maker.VerifyToken(refreshToken)
+		require.NoError(t, err)
+		require.ErrorIs(t, payload.CheckType(AccessToken), ErrWrongTokenType)
+		require.NoError(t, payload.CheckType(RefreshToken))
+	}
+}
@@ -27,12 +27,31 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return &maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", nil, err
 	}
+	return maker.signToken(payload)
+}
+
+func (maker *PasetoMaker) CreateRefreshToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewRefreshPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *PasetoMaker) CreateScopedToken(username string, audience string, scopes []string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(username, audience, scopes, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
 
+func (maker *PasetoMaker) signToken(payload *Payload) (string, *Payload, error) {
 	encrypt, err := maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
 	if err != nil {
 		return "", nil, err
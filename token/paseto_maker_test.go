@@ -16,7 +16,7 @@ func TestPasetoMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := time.Now().Add(duration)
 
-	token, payload, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, utils.CustomerRole, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -27,6 +27,7 @@ func TestPasetoMaker(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.UserName)
+	require.Equal(t, utils.CustomerRole, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -35,7 +36,7 @@ func TestPasetoExpiredToken(t *testing.T) {
 	maker, err := NewPasetoMaker(utils.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(utils.RandomString(32), -time.Minute)
+	token, payload, err := maker.CreateToken(utils.RandomString(32), utils.CustomerRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -44,3 +45,24 @@ func TestPasetoExpiredToken(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, payload)
 }
+
+func TestPasetoScopedToken(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32))
+	require.NoError(t, err)
+
+	username := utils.RandomOwner()
+	audience := utils.RandomString(16)
+	scopes := []string{"accounts:read"}
+
+	token, _, err := maker.CreateScopedToken(username, audience, scopes, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.UserName)
+	require.Empty(t, payload.Role)
+	require.Equal(t, audience, payload.Audience)
+	require.True(t, payload.IsScoped())
+	require.True(t, payload.HasScope("accounts:read"))
+	require.False(t, payload.HasScope("transfers:write"))
+}
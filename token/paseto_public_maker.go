@@ -0,0 +1,166 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const pasetoV4PublicHeader = "v4.public."
+
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// PasetoPublicMaker signs PASETO v4.public tokens with the active key of the ring, the kid goes in the footer
+type PasetoPublicMaker struct {
+	keys *KeyRing
+}
+
+func NewPasetoPublicMaker(keys *KeyRing) (Maker, error) {
+	return &PasetoPublicMaker{keys: keys}, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *PasetoPublicMaker) CreateRefreshToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewRefreshPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *PasetoPublicMaker) CreateScopedToken(username string, audience string, scopes []string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewScopedPayload(username, audience, scopes, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	return maker.signToken(payload)
+}
+
+func (maker *PasetoPublicMaker) signToken(payload *Payload) (string, *Payload, error) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: maker.keys.activeKeyID})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signPasetoV4Public(maker.keys.signingKey, message, footer), payload, nil
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	return VerifyPasetoPublicToken(token, maker.keys)
+}
+
+// KeyRing returns the keys the tokens are signed with
+func (maker *PasetoPublicMaker) KeyRing() *KeyRing {
+	return maker.keys
+}
+
+// VerifyPasetoPublicToken verifies a v4.public token with the key of the kid in its footer
+func VerifyPasetoPublicToken(token string, keys PublicKeySource) (*Payload, error) {
+	message, err := verifyPasetoV4Public(token, func(footer []byte) (ed25519.PublicKey, error) {
+		var f pasetoFooter
+		if err := json.Unmarshal(footer, &f); err != nil {
+			return nil, ErrInvalidToken
+		}
+		return keys.PublicKey(f.KeyID)
+	})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err = json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// signPasetoV4Public follows https://github.com/paseto-standard/paseto-spec/blob/master/docs/01-Protocol-Versions/Version4.md#sign
+func signPasetoV4Public(key ed25519.PrivateKey, message, footer []byte) string {
+	signature := ed25519.Sign(key, pae([]byte(pasetoV4PublicHeader), message, footer, nil))
+
+	token := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// verifyPasetoV4Public returns the message of the token, the footer is handed to keyFunc to pick the verification key
+func verifyPasetoV4Public(token string, keyFunc func(footer []byte) (ed25519.PublicKey, error)) ([]byte, error) {
+	if !strings.HasPrefix(token, pasetoV4PublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, pasetoV4PublicHeader), ".")
+	if len(parts) > 2 {
+		return nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	var footer []byte
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	key, err := keyFunc(footer)
+	if err != nil {
+		return nil, err
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(key, pae([]byte(pasetoV4PublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	return message, nil
+}
+
+// pae is the pre-authentication encoding of the PASETO spec
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	le64 := func(n int) {
+		var b [8]byte
+		// the most significant bit is cleared for interoperability with languages without unsigned integers
+		binary.LittleEndian.PutUint64(b[:], uint64(n)&^(1<<63))
+		buf.Write(b[:])
+	}
+
+	le64(len(pieces))
+	for _, piece := range pieces {
+		le64(len(piece))
+		buf.Write(piece)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,116 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	username := utils.RandomOwner()
+	duration := time.Minute
+	issuedAt := time.Now()
+	expiredAt := time.Now().Add(duration)
+
+	token, payload, err := maker.CreateToken(username, utils.CustomerRole, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.UserName)
+	require.Equal(t, utils.CustomerRole, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestPasetoPublicExpiredToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(utils.RandomString(32), utils.CustomerRole, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicTamperedToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// the signature belongs to another key
+	other, err := NewPasetoPublicMaker(randomKeyRing(t, "key-1"))
+	require.NoError(t, err)
+
+	payload, err := other.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicKeyRotation(t *testing.T) {
+	oldRing := randomKeyRing(t, "key-1")
+	oldMaker, err := NewPasetoPublicMaker(oldRing)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// key-2 becomes the active key and key-1 is still accepted
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	oldKey, err := oldRing.PublicKey("key-1")
+	require.NoError(t, err)
+
+	ring, err := NewKeyRing("key-2", signingKey, map[string]ed25519.PublicKey{"key-1": oldKey})
+	require.NoError(t, err)
+	maker, err := NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	// once key-1 is retired its tokens are rejected
+	ring, err = NewKeyRing("key-2", signingKey, nil)
+	require.NoError(t, err)
+	maker, err = NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+// TestPasetoV4PublicVector checks the 4-S-1 test vector of the PASETO spec
+func TestPasetoV4PublicVector(t *testing.T) {
+	seed, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774")
+	require.NoError(t, err)
+	key := ed25519.NewKeyFromSeed(seed)
+
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	token := signPasetoV4Public(key, message, nil)
+	require.Equal(t, expected, token)
+
+	verified, err := verifyPasetoV4Public(token, func(footer []byte) (ed25519.PublicKey, error) {
+		return key.Public().(ed25519.PublicKey), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, message, verified)
+}
@@ -0,0 +1,90 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token was issued before the last password change")
+
+// PasswordChangedAtFunc returns when the user last changed its password
+type PasswordChangedAtFunc func(ctx context.Context, username string) (time.Time, error)
+
+type passwordChange struct {
+	changedAt time.Time
+	cachedAt  time.Time
+}
+
+// PasswordChangeCache rejects tokens issued before the user's last password change.
+// Lookups are cached for ttl to avoid a db hit per request, so other instances notice a change after at most ttl.
+// The expired entries are deleted at most once per ttl when a new one is stored, so the users that stopped
+// sending requests don't stay in memory
+type PasswordChangeCache struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	lookup   PasswordChangedAtFunc
+	entries  map[string]passwordChange
+	prunedAt time.Time
+}
+
+func NewPasswordChangeCache(ttl time.Duration, lookup PasswordChangedAtFunc) *PasswordChangeCache {
+	return &PasswordChangeCache{
+		ttl:     ttl,
+		lookup:  lookup,
+		entries: make(map[string]passwordChange),
+	}
+}
+
+// Check returns ErrTokenRevoked if the token was issued before the password changed, lookup errors are returned as they are
+func (c *PasswordChangeCache) Check(ctx context.Context, payload *Payload) error {
+	changedAt, err := c.passwordChangedAt(ctx, payload.UserName)
+	if err != nil {
+		return err
+	}
+
+	if payload.IssuedAt.Before(changedAt) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// Set stores a password change made by this instance so it's enforced right away
+func (c *PasswordChangeCache) Set(username string, changedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.prunedAt) >= c.ttl {
+		c.prune(now)
+	}
+	c.entries[username] = passwordChange{changedAt: changedAt, cachedAt: now}
+}
+
+// prune deletes the entries older than ttl, the caller holds the lock
+func (c *PasswordChangeCache) prune(now time.Time) {
+	for username, entry := range c.entries {
+		if now.Sub(entry.cachedAt) >= c.ttl {
+			delete(c.entries, username)
+		}
+	}
+	c.prunedAt = now
+}
+
+func (c *PasswordChangeCache) passwordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[username]
+	c.mu.RUnlock()
+	if ok && time.Since(entry.cachedAt) < c.ttl {
+		return entry.changedAt, nil
+	}
+
+	changedAt, err := c.lookup(ctx, username)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c.Set(username, changedAt)
+	return changedAt, nil
+}
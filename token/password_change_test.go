@@ -0,0 +1,80 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPasswordChangeCache(t *testing.T) {
+	username := utils.RandomOwner()
+	changedAt := time.Now().Add(-time.Hour)
+	lookups := 0
+
+	cache := NewPasswordChangeCache(time.Minute, func(ctx context.Context, u string) (time.Time, error) {
+		require.Equal(t, username, u)
+		lookups++
+		return changedAt, nil
+	})
+
+	payload, err := NewPayload(username, utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Check(context.Background(), payload))
+	require.NoError(t, cache.Check(context.Background(), payload))
+	require.Equal(t, 1, lookups)
+
+	// a password change made by this instance is enforced without waiting for the ttl
+	cache.Set(username, time.Now().Add(time.Second))
+	require.ErrorIs(t, cache.Check(context.Background(), payload), ErrTokenRevoked)
+	require.Equal(t, 1, lookups)
+}
+
+func TestPasswordChangeCacheExpiredEntry(t *testing.T) {
+	username := utils.RandomOwner()
+	changedAt := time.Now().Add(-time.Hour)
+
+	cache := NewPasswordChangeCache(time.Millisecond, func(ctx context.Context, u string) (time.Time, error) {
+		return changedAt, nil
+	})
+
+	payload, err := NewPayload(username, utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, cache.Check(context.Background(), payload))
+
+	// another instance changed the password, it is noticed once the entry expires
+	changedAt = time.Now().Add(time.Second)
+	time.Sleep(5 * time.Millisecond)
+	require.ErrorIs(t, cache.Check(context.Background(), payload), ErrTokenRevoked)
+}
+
+func TestPasswordChangeCacheLookupError(t *testing.T) {
+	lookupErr := errors.New("db is down")
+	cache := NewPasswordChangeCache(time.Minute, func(ctx context.Context, u string) (time.Time, error) {
+		return time.Time{}, lookupErr
+	})
+
+	payload, err := NewPayload(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	require.ErrorIs(t, cache.Check(context.Background(), payload), lookupErr)
+}
+
+func TestPasswordChangeCachePrunesExpiredEntries(t *testing.T) {
+	cache := NewPasswordChangeCache(time.Millisecond, func(ctx context.Context, u string) (time.Time, error) {
+		return time.Time{}, nil
+	})
+
+	for i := 0; i < 10; i++ {
+		cache.Set(utils.RandomOwner(), time.Now())
+	}
+
+	// the expired entries are deleted when the next one is stored
+	time.Sleep(5 * time.Millisecond)
+	username := utils.RandomOwner()
+	cache.Set(username, time.Now())
+	require.Len(t, cache.entries, 1)
+	require.Contains(t, cache.entries, username)
+}
@@ -8,15 +8,41 @@ import (
 
 var ErrExpiredToken = errors.New("token is expired")
 var ErrInvalidToken = errors.New("token is invalid")
+var ErrWrongTokenType = errors.New("token has the wrong type")
+
+// The token types, every maker signs them with the same keys so the type claim keeps a refresh token from being
+// accepted as an access token
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
 
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	UserName  string    `json:"user_name"`
+	Role      string    `json:"role"`
+	Type      string    `json:"token_type"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// APIKeyID is only set when the request is authenticated with an API key
+	APIKeyID int64 `json:"api_key_id,omitempty"`
+	// Scopes restrict API keys and the tokens issued to OAuth clients
+	Scopes []string `json:"scopes,omitempty"`
+	// Audience is the OAuth client the token was issued to
+	Audience string `json:"audience,omitempty"`
+}
+
+// NewPayload returns the payload of an access token
+func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	return newPayload(username, role, AccessToken, duration)
+}
+
+// NewRefreshPayload returns the payload of a refresh token, it's only accepted to renew the access token
+func NewRefreshPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	return newPayload(username, role, RefreshToken, duration)
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func newPayload(username string, role string, tokenType string, duration time.Duration) (*Payload, error) {
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return nil, errors.New("error generating token id")
@@ -25,6 +51,8 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := Payload{
 		ID:        id,
 		UserName:  username,
+		Role:      role,
+		Type:      tokenType,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -32,9 +60,48 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	return &payload, nil
 }
 
+// NewScopedPayload returns the payload of a token issued to the audience, it has no role and only grants the scopes
+func NewScopedPayload(username string, audience string, scopes []string, duration time.Duration) (*Payload, error) {
+	payload, err := NewPayload(username, "", duration)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Audience = audience
+	payload.Scopes = scopes
+	return payload, nil
+}
+
 func (p Payload) Valid() error {
 	if time.Now().After(p.ExpiredAt) {
 		return ErrExpiredToken
 	}
 	return nil
 }
+
+// CheckType returns ErrWrongTokenType unless the token is of the type, the tokens issued without a type are rejected
+func (p Payload) CheckType(tokenType string) error {
+	if p.Type != tokenType {
+		return ErrWrongTokenType
+	}
+	return nil
+}
+
+// HasScope reports if the payload grants the scope, access tokens of users are not restricted by scopes
+func (p Payload) HasScope(scope string) bool {
+	if !p.IsScoped() {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScoped reports if the payload belongs to an API key or an OAuth client instead of a user session
+func (p Payload) IsScoped() bool {
+	return p.APIKeyID != 0 || p.Audience != ""
+}
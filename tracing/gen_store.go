@@ -0,0 +1,182 @@
+//go:build ignore
+
+// gen_store writes store.go, the db.Store starting a span around every method of the db.Querier and db.Store
+// interfaces. It runs with go generate after sqlc or a new transaction changes them
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	dbDir      = "../db/sqlc"
+	dbPackage  = "db"
+	dbImport   = "github.com/micaelapucciariello/simplebank/db/sqlc"
+	outputFile = "store.go"
+)
+
+type method struct {
+	name    string
+	params  []*ast.Field
+	results []*ast.Field
+}
+
+func main() {
+	fset := token.NewFileSet()
+	// the import paths of the packages used by the method signatures, by name
+	imports := map[string]string{}
+	var methods []method
+
+	for _, file := range []string{"querier.go", "store.go"} {
+		f, err := parser.ParseFile(fset, filepath.Join(dbDir, file), nil, 0)
+		if err != nil {
+			log.Fatalf("cannot parse %s: %s", file, err)
+		}
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = path
+		}
+
+		ast.Inspect(f, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok || (spec.Name.Name != "Querier" && spec.Name.Name != "Store") {
+				return true
+			}
+			for _, field := range spec.Type.(*ast.InterfaceType).Methods.List {
+				// the Querier embedded in Store
+				if len(field.Names) == 0 {
+					continue
+				}
+				fn := field.Type.(*ast.FuncType)
+				m := method{name: field.Names[0].Name, params: fn.Params.List}
+				if fn.Results != nil {
+					m.results = fn.Results.List
+				}
+				methods = append(methods, m)
+			}
+			return false
+		})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+	used := map[string]bool{dbImport: true}
+	var body bytes.Buffer
+	for _, m := range methods {
+		writeMethod(&body, fset, m, imports, used)
+	}
+
+	var paths []string
+	for path := range used {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gen_store.go. DO NOT EDIT.\n\npackage tracing\n\nimport (\n")
+	for _, path := range paths {
+		if path == dbImport {
+			fmt.Fprintf(&out, "\t%s %q\n", dbPackage, path)
+			continue
+		}
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(`)
+
+// store starts a span around every query and transaction of the store it wraps. It doesn't embed db.Store,
+// a method missing after the interfaces change fails the build until store.go is generated again
+type store struct {
+	next db.Store
+}
+
+// NewStore returns a db.Store tracing the queries and transactions of s
+func NewStore(s db.Store) db.Store {
+	return &store{next: s}
+}
+`)
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatalf("cannot format %s: %s", outputFile, err)
+	}
+	if err = os.WriteFile(outputFile, src, 0644); err != nil {
+		log.Fatalf("cannot write %s: %s", outputFile, err)
+	}
+}
+
+func writeMethod(w *bytes.Buffer, fset *token.FileSet, m method, imports map[string]string, used map[string]bool) {
+	var params, args []string
+	for _, field := range m.params {
+		typ := typeString(fset, field.Type, imports, used)
+		for _, name := range field.Names {
+			params = append(params, name.Name+" "+typ)
+			args = append(args, name.Name)
+		}
+	}
+	call := fmt.Sprintf("s.next.%s(%s)", m.name, strings.Join(args, ", "))
+
+	if len(m.results) == 1 {
+		fmt.Fprintf(w, `
+func (s *store) %s(%s) error {
+	ctx, span := startSpan(ctx, %q)
+	err := %s
+	endSpan(span, err)
+	return err
+}
+`, m.name, strings.Join(params, ", "), m.name, call)
+		return
+	}
+
+	result := typeString(fset, m.results[0].Type, imports, used)
+	fmt.Fprintf(w, `
+func (s *store) %s(%s) (%s, error) {
+	ctx, span := startSpan(ctx, %q)
+	result, err := %s
+	endSpan(span, err)
+	return result, err
+}
+`, m.name, strings.Join(params, ", "), result, m.name, call)
+}
+
+// typeString prints the type as seen from the tracing package, the types declared in db get qualified
+func typeString(fset *token.FileSet, expr ast.Expr, imports map[string]string, used map[string]bool) string {
+	expr = qualify(expr, imports, used)
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		log.Fatalf("cannot print type: %s", err)
+	}
+	return buf.String()
+}
+
+func qualify(expr ast.Expr, imports map[string]string, used map[string]bool) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent(dbPackage), Sel: ast.NewIdent(t.Name)}
+		}
+	case *ast.SelectorExpr:
+		used[imports[t.X.(*ast.Ident).Name]] = true
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(t.X, imports, used)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: qualify(t.Elt, imports, used)}
+	}
+	return expr
+}
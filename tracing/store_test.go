@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+// recordSpans makes the global tracer provider record the spans until the test ends
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+	return recorder
+}
+
+// hasSpan matches contexts carrying the span of the store call
+type hasSpan struct{}
+
+func (hasSpan) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && trace.SpanFromContext(ctx).SpanContext().IsValid()
+}
+
+func (hasSpan) String() string {
+	return "has a span"
+}
+
+func TestStore(t *testing.T) {
+	accountID := utils.RandomInt(1, 1000)
+	params := db.TransferTxParams{
+		FromAccountID: utils.RandomInt(1, 1000),
+		ToAccountID:   utils.RandomInt(1, 1000),
+		Amount:        utils.RandomInt(1, 1000),
+	}
+
+	testCases := []struct {
+		name       string
+		operation  string
+		buildStubs func(store *mockdb.MockStore)
+		call       func(store db.Store) error
+		status     codes.Code
+	}{
+		{
+			name:      "query",
+			operation: "GetAccount",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(hasSpan{}, gomock.Eq(accountID)).Times(1).Return(db.Account{ID: accountID}, nil)
+			},
+			call: func(store db.Store) error {
+				_, err := store.GetAccount(context.Background(), accountID)
+				return err
+			},
+			status: codes.Unset,
+		},
+		{
+			name:      "no rows",
+			operation: "GetAccount",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(hasSpan{}, gomock.Eq(accountID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			call: func(store db.Store) error {
+				_, err := store.GetAccount(context.Background(), accountID)
+				require.Equal(t, sql.ErrNoRows, err)
+				return nil
+			},
+			status: codes.Unset,
+		},
+		{
+			name:      "failed transaction",
+			operation: "TransferTx",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(hasSpan{}, gomock.Eq(params)).Times(1).Return(db.TransferTxResult{}, sql.ErrConnDone)
+			},
+			call: func(store db.Store) error {
+				_, err := store.TransferTx(context.Background(), params)
+				require.Equal(t, sql.ErrConnDone, err)
+				return nil
+			},
+			status: codes.Error,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(mockStore)
+
+			require.NoError(t, tc.call(NewStore(mockStore)))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			require.Equal(t, tc.operation, spans[0].Name())
+			require.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+			require.Contains(t, spans[0].Attributes(), semconv.DBOperation(tc.operation))
+			require.Equal(t, tc.status, spans[0].Status().Code)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), utils.Config{TracingExporter: ExporterStdout}, &buf)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer(instrumentationName).Start(context.Background(), "test span")
+	span.End()
+
+	// spans are written when the batch is flushed
+	require.NoError(t, shutdown(context.Background()))
+	require.Contains(t, buf.String(), `"Name":"test span"`)
+	require.Contains(t, buf.String(), serviceName)
+
+	shutdown, err = Setup(context.Background(), utils.Config{TracingExporter: ExporterNone}, &buf)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), utils.Config{TracingExporter: "zipkin"}, &buf)
+	require.Error(t, err)
+}
@@ -7,13 +7,61 @@ import (
 
 // Config these values are read by viper from the config.env configuration file
 type Config struct {
-	DriverName           string        `mapstructure:"DB_DRIVER"`
-	SourceName           string        `mapstructure:"DB_SOURCE"`
-	HTTPServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	TokenDuration        time.Duration `mapstructure:"TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	DriverName             string        `mapstructure:"DB_DRIVER"`
+	SourceName             string        `mapstructure:"DB_SOURCE"`
+	HTTPServerAddress      string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GRPCServerAddress      string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	ShutdownTimeout        time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	LogLevel               string        `mapstructure:"LOG_LEVEL"`
+	TracingExporter        string        `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint    string        `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure    bool          `mapstructure:"TRACING_OTLP_INSECURE"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenType              string        `mapstructure:"TOKEN_TYPE"`
+	TokenActiveKeyID       string        `mapstructure:"TOKEN_ACTIVE_KEY_ID"`
+	TokenKeyFiles          []string      `mapstructure:"TOKEN_KEY_FILES"`
+	TokenKeysMaxAge        time.Duration `mapstructure:"TOKEN_KEYS_MAX_AGE"`
+	TokenDuration          time.Duration `mapstructure:"TOKEN_DURATION"`
+	RefreshTokenDuration   time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	PasswordChangeCacheTTL time.Duration `mapstructure:"PASSWORD_CHANGE_CACHE_TTL"`
+	WebhookMaxAttempts     int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoffBase     time.Duration `mapstructure:"WEBHOOK_BACKOFF_BASE"`
+	WebhookPollInterval    time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	MailerDriver           string        `mapstructure:"MAILER_DRIVER"`
+	MailOutputDir          string        `mapstructure:"MAIL_OUTPUT_DIR"`
+	SMTPHost               string        `mapstructure:"SMTP_HOST"`
+	SMTPPort               int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername           string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword           string        `mapstructure:"SMTP_PASSWORD"`
+	EmailSenderName        string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress     string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	VerifyEmailURL         string        `mapstructure:"VERIFY_EMAIL_URL"`
+	VerifyEmailDuration    time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	ResetPasswordURL       string        `mapstructure:"RESET_PASSWORD_URL"`
+	ResetPasswordDuration  time.Duration `mapstructure:"RESET_PASSWORD_DURATION"`
+	MFAIssuer              string        `mapstructure:"MFA_ISSUER"`
+	MFAChallengeDuration   time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	MFATransferThreshold   int64         `mapstructure:"MFA_TRANSFER_THRESHOLD"`
+	LoginMaxUserFailures   int32         `mapstructure:"LOGIN_MAX_USER_FAILURES"`
+	LoginMaxIPFailures     int32         `mapstructure:"LOGIN_MAX_IP_FAILURES"`
+	LoginFailureWindow     time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginFailureDelay      time.Duration `mapstructure:"LOGIN_FAILURE_DELAY"`
+	LoginLockoutDuration   time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	OAuthCodeDuration      time.Duration `mapstructure:"OAUTH_CODE_DURATION"`
+	OAuthTokenDuration     time.Duration `mapstructure:"OAUTH_TOKEN_DURATION"`
+	// ActivityStreamsPerUser caps the open activity streams of each user, 0 means no limit
+	ActivityStreamsPerUser    int           `mapstructure:"ACTIVITY_STREAMS_PER_USER"`
+	ActivityHeartbeatInterval time.Duration `mapstructure:"ACTIVITY_HEARTBEAT_INTERVAL"`
+	// HealthCheckInterval is how often the readiness is checked for the gRPC health service
+	HealthCheckInterval time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	HealthCheckTimeout  time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	// Servers selects which of the gin, gateway and grpc servers the binary runs, with GinMountGateway the
+	// Gin server also serves the gateway routes under /v1
+	Servers          []string `mapstructure:"SERVERS"`
+	GinServerAddress string   `mapstructure:"GIN_SERVER_ADDRESS"`
+	GinMountGateway  bool     `mapstructure:"GIN_MOUNT_GATEWAY"`
+	// AdminUsernames are promoted to admin on every start, it's how the first admin of a deployment is created
+	AdminUsernames []string `mapstructure:"ADMIN_USERNAMES"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
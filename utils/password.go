@@ -3,6 +3,12 @@ package utils
 import (
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"sync"
+)
+
+var (
+	dummyHashedPassword     []byte
+	dummyHashedPasswordOnce sync.Once
 )
 
 func HashPassword(password string) (string, error) {
@@ -18,3 +24,12 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// CheckDummyPassword takes as long as CheckPassword, it's used when the user doesn't exist
+// so the response time doesn't reveal which usernames are registered
+func CheckDummyPassword(password string) {
+	dummyHashedPasswordOnce.Do(func() {
+		dummyHashedPassword, _ = bcrypt.GenerateFromPassword([]byte(RandomString(16)), 10)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHashedPassword, []byte(password))
+}
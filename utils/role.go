@@ -0,0 +1,16 @@
+package utils
+
+const (
+	CustomerRole = "customer"
+	BankerRole   = "banker"
+	AdminRole    = "admin"
+)
+
+// IsSupportedRole returns true if the role is supported
+func IsSupportedRole(role string) bool {
+	switch role {
+	case CustomerRole, BankerRole, AdminRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// RandomSecret returns n bytes from crypto/rand hex encoded, to be used for codes and tokens sent to users
+func RandomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate secret: %s", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashSecret returns the sha256 of a secret hex encoded, so single-use tokens are not stored in clear text
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
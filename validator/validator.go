@@ -2,13 +2,17 @@ package validator
 
 import (
 	"fmt"
+	"github.com/micaelapucciariello/simplebank/utils"
 	"net/mail"
+	"net/url"
 	"regexp"
 )
 
 var (
 	isValidUsername = regexp.MustCompile("^[a-z0-9_]+$").MatchString
 	isValidFullName = regexp.MustCompile("^[a-zA-Z]+$").MatchString
+	isValidHex      = regexp.MustCompile("^[a-f0-9]+$").MatchString
+	isValidTotpCode = regexp.MustCompile("^[0-9]{6}$").MatchString
 )
 
 func ValidateLength(s string, min, max int) error {
@@ -60,3 +64,48 @@ func ValidateEmail(email string) error {
 	}
 	return nil
 }
+
+func ValidateURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme: must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid url: missing host")
+	}
+	return nil
+}
+
+// ValidateSecretCode checks codes generated with utils.RandomSecret(32)
+func ValidateSecretCode(code string) error {
+	if len(code) != 64 || !isValidHex(code) {
+		return fmt.Errorf("invalid secret code: must have 64 hex characters")
+	}
+	return nil
+}
+
+// ValidateTotpCode checks the 6 digit codes of authenticator apps
+func ValidateTotpCode(code string) error {
+	if !isValidTotpCode(code) {
+		return fmt.Errorf("invalid code: must have 6 digits")
+	}
+	return nil
+}
+
+func ValidateID(id int64) error {
+	if id < 1 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	return nil
+}
+
+func ValidateCurrency(currency string) error {
+	if !utils.IsSupported(currency) {
+		return fmt.Errorf("unsupported currency: %s", currency)
+	}
+	return nil
+}
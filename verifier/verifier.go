@@ -0,0 +1,165 @@
+// Package verifier validates SimpleBank access tokens with the public keys published at /.well-known/jwks.json,
+// so other services don't need to call the bank on every request
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"github.com/micaelapucciariello/simplebank/token"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// minRefreshInterval limits how often an unknown kid or a failed fetch triggers a fetch
+	minRefreshInterval = 30 * time.Second
+	fetchTimeout       = 10 * time.Second
+)
+
+// Verifier fetches the JWK set and caches it for ttl. A token signed with an unknown kid refreshes the keys right away
+// so rotations are picked up before the cache expires, and the last known keys are kept if a fetch fails
+type Verifier struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]ed25519.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed once the fetch in flight is done, refreshErr is its result
+	refreshing chan struct{}
+	refreshErr error
+}
+
+func New(jwksURL string, ttl time.Duration) *Verifier {
+	return &Verifier{
+		url:    jwksURL,
+		ttl:    ttl,
+		client: &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+// VerifyToken validates a PASETO v4.public token or an EdDSA JWT, the refresh tokens are signed with the same keys
+// and are rejected with token.ErrWrongTokenType
+func (v *Verifier) VerifyToken(accessToken string) (*token.Payload, error) {
+	var payload *token.Payload
+	var err error
+	if strings.HasPrefix(accessToken, "v4.public.") {
+		payload, err = token.VerifyPasetoPublicToken(accessToken, v)
+	} else {
+		payload, err = token.VerifyJWTPublicToken(accessToken, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = payload.CheckType(token.AccessToken); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// PublicKey returns the key of kid, fetching the JWK set if it's not cached
+func (v *Verifier) PublicKey(kid string) (ed25519.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.ttl
+	v.mu.Unlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	err := v.refresh()
+
+	v.mu.Lock()
+	key, ok = v.keys[kid]
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, token.ErrUnknownKeyID
+}
+
+// refresh fetches the JWK set unless the last attempt, successful or not, is too recent, so an unreachable
+// endpoint or tokens with made up kids don't cause a request each. The lock isn't held during the fetch,
+// concurrent callers wait for the fetch in flight instead of starting their own
+func (v *Verifier) refresh() error {
+	v.mu.Lock()
+	if done := v.refreshing; done != nil {
+		v.mu.Unlock()
+		<-done
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		return v.refreshErr
+	}
+
+	interval := minRefreshInterval
+	if v.ttl < interval {
+		interval = v.ttl
+	}
+	if time.Since(v.attemptedAt) <= interval {
+		v.mu.Unlock()
+		return nil
+	}
+
+	done := make(chan struct{})
+	v.refreshing = done
+	v.attemptedAt = time.Now()
+	v.mu.Unlock()
+
+	keys, err := v.fetch(context.Background())
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys = keys
+		v.fetchedAt = time.Now()
+	}
+	v.refreshing = nil
+	v.refreshErr = err
+	v.mu.Unlock()
+	close(done)
+
+	return err
+}
+
+func (v *Verifier) fetch(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch token keys: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot fetch token keys: unexpected status %d", rsp.StatusCode)
+	}
+
+	var set token.JWKSet
+	if err = json.NewDecoder(rsp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("cannot decode token keys: %w", err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// keys of other types are skipped
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,182 @@
+package verifier
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"github.com/micaelapucciariello/simplebank/token"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newKeyRing(t *testing.T, kid string, previous map[string]ed25519.PublicKey) *token.KeyRing {
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	ring, err := token.NewKeyRing(kid, signingKey, previous)
+	require.NoError(t, err)
+	return ring
+}
+
+// newJWKSServer serves the JWK set of the ring returned by keys and counts the fetches
+func newJWKSServer(t *testing.T, keys func() *token.KeyRing) (*httptest.Server, *int32) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		require.NoError(t, json.NewEncoder(w).Encode(keys().JWKS()))
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestVerifyToken(t *testing.T) {
+	ring := newKeyRing(t, "key-1", nil)
+	server, fetches := newJWKSServer(t, func() *token.KeyRing { return ring })
+
+	pasetoMaker, err := token.NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+	jwtMaker, err := token.NewJWTPublicMaker(ring)
+	require.NoError(t, err)
+
+	verifier := New(server.URL, time.Minute)
+
+	for _, maker := range []token.Maker{pasetoMaker, jwtMaker} {
+		username := utils.RandomOwner()
+		accessToken, _, err := maker.CreateToken(username, utils.CustomerRole, time.Minute)
+		require.NoError(t, err)
+
+		payload, err := verifier.VerifyToken(accessToken)
+		require.NoError(t, err)
+		require.Equal(t, username, payload.UserName)
+	}
+
+	// the keys are cached
+	require.Equal(t, int32(1), atomic.LoadInt32(fetches))
+}
+
+func TestVerifyTokenSignedByOtherKey(t *testing.T) {
+	ring := newKeyRing(t, "key-1", nil)
+	server, _ := newJWKSServer(t, func() *token.KeyRing { return ring })
+
+	maker, err := token.NewPasetoPublicMaker(newKeyRing(t, "key-1", nil))
+	require.NoError(t, err)
+
+	accessToken, _, err := maker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := New(server.URL, time.Minute).VerifyToken(accessToken)
+	require.EqualError(t, err, token.ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestVerifyRefreshToken(t *testing.T) {
+	ring := newKeyRing(t, "key-1", nil)
+	server, _ := newJWKSServer(t, func() *token.KeyRing { return ring })
+
+	maker, err := token.NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+
+	refreshToken, _, err := maker.CreateRefreshToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := New(server.URL, time.Minute).VerifyToken(refreshToken)
+	require.ErrorIs(t, err, token.ErrWrongTokenType)
+	require.Nil(t, payload)
+}
+
+func TestVerifyTokenAfterRotation(t *testing.T) {
+	ring := newKeyRing(t, "key-1", nil)
+	server, fetches := newJWKSServer(t, func() *token.KeyRing { return ring })
+
+	verifier := New(server.URL, time.Hour)
+	maker, err := token.NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+
+	accessToken, _, err := maker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(accessToken)
+	require.NoError(t, err)
+
+	// key-2 is published, the verifier only refetches once minRefreshInterval has passed
+	oldKey, err := ring.PublicKey("key-1")
+	require.NoError(t, err)
+	ring = newKeyRing(t, "key-2", map[string]ed25519.PublicKey{"key-1": oldKey})
+
+	maker, err = token.NewPasetoPublicMaker(ring)
+	require.NoError(t, err)
+	accessToken, _, err = maker.CreateToken(utils.RandomOwner(), utils.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = verifier.VerifyToken(accessToken)
+	require.Error(t, err)
+
+	verifier.attemptedAt = time.Now().Add(-minRefreshInterval)
+	_, err = verifier.VerifyToken(accessToken)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(fetches))
+}
+
+func TestPublicKeyFetchFailed(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := New(server.URL, time.Minute)
+
+	_, err := verifier.PublicKey("key-1")
+	require.ErrorContains(t, err, "unexpected status 503")
+
+	// the failed fetch backs off like a successful one
+	_, err = verifier.PublicKey("key-1")
+	require.ErrorIs(t, err, token.ErrUnknownKeyID)
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestPublicKeyConcurrentFetch(t *testing.T) {
+	ring := newKeyRing(t, "key-1", nil)
+
+	var fetches int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		require.NoError(t, json.NewEncoder(w).Encode(ring.JWKS()))
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := New(server.URL, time.Minute)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.PublicKey("key-1")
+			errs <- err
+		}()
+	}
+
+	// the callers wait for the fetch in flight, the lock isn't held during it
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 1 }, time.Second, 10*time.Millisecond)
+	verifier.mu.Lock()
+	require.NotNil(t, verifier.refreshing)
+	verifier.mu.Unlock()
+	close(release)
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
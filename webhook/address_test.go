@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{name: "https", url: "https://hooks.example.com/bank", valid: true},
+		{name: "http", url: "http://hooks.example.com/bank", valid: true},
+		{name: "public ip", url: "https://93.184.216.34/bank", valid: true},
+		{name: "other scheme", url: "ftp://hooks.example.com/bank"},
+		{name: "localhost", url: "http://localhost:8080/bank"},
+		{name: "loopback", url: "http://127.0.0.1/bank"},
+		{name: "ipv6 loopback", url: "http://[::1]/bank"},
+		{name: "private network", url: "http://10.0.0.5/bank"},
+		{name: "metadata endpoint", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "unspecified", url: "http://0.0.0.0/bank"},
+		{name: "shared address space", url: "http://100.64.0.1/bank"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateURL(tc.url)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
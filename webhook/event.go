@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// event types a subscription can listen to
+const (
+	EventAccountCreated   = "account.created"
+	EventTransferSent     = "transfer.sent"
+	EventTransferReceived = "transfer.received"
+)
+
+// delivery statuses persisted in webhook_deliveries.status
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// IsSupportedEvent returns true if the event type can be subscribed to
+func IsSupportedEvent(eventType string) bool {
+	switch eventType {
+	case EventAccountCreated, EventTransferSent, EventTransferReceived:
+		return true
+	}
+	return false
+}
+
+// Envelope is the JSON body POSTed to the subscriber
+type Envelope struct {
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewSecret generates a random signing secret for a subscription
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"time"
+)
+
+// Publisher queues a delivery for every active subscription listening to an event
+type Publisher struct {
+	store db.Store
+}
+
+func NewPublisher(store db.Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+// Publish stores one pending delivery per matching subscription of the owner.
+// The actual HTTP call is done asynchronously by the Worker
+func (p *Publisher) Publish(ctx context.Context, owner string, eventType string, data interface{}) error {
+	return p.PublishWith(ctx, p.store, owner, eventType, data)
+}
+
+// PublishWith stores the deliveries with q, within a transaction they're only queued if it commits
+func (p *Publisher) PublishWith(ctx context.Context, q db.Querier, owner string, eventType string, data interface{}) error {
+	subscriptions, err := q.ListWebhookSubscriptionsByEvent(ctx, db.ListWebhookSubscriptionsByEventParams{
+		Owner:     owner,
+		EventType: eventType,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot list webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot encode webhook data: %w", err)
+	}
+
+	payload, err := json.Marshal(Envelope{
+		Event:     eventType,
+		CreatedAt: time.Now(),
+		Data:      rawData,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode webhook payload: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		_, err = q.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			SubscriptionID: subscription.ID,
+			EventType:      eventType,
+			Payload:        payload,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot create webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TransferEvents returns the AfterTransfer hook of TransferTx, it queues the transfer.sent and transfer.received events
+// within the transaction of the transfer
+func (p *Publisher) TransferEvents(ctx context.Context) func(q db.Querier, result db.TransferTxResult) error {
+	return func(q db.Querier, result db.TransferTxResult) error {
+		if err := p.PublishWith(ctx, q, result.FromAccountID.Owner, EventTransferSent, result.Transfer); err != nil {
+			return err
+		}
+		return p.PublishWith(ctx, q, result.ToAccountID.Owner, EventTransferReceived, result.Transfer)
+	}
+}
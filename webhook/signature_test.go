@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := utils.RandomString(32)
+	body := []byte(`{"event":"transfer.received"}`)
+	timestamp := time.Now().Unix()
+
+	signature := Sign(secret, timestamp, body)
+	require.NotEmpty(t, signature)
+
+	err := Verify(secret, signature, strconv.FormatInt(timestamp, 10), body, time.Minute)
+	require.NoError(t, err)
+}
+
+func TestVerifyInvalidSignature(t *testing.T) {
+	secret := utils.RandomString(32)
+	body := []byte(`{"event":"transfer.received"}`)
+	timestamp := time.Now().Unix()
+	signature := Sign(secret, timestamp, body)
+
+	err := Verify(utils.RandomString(32), signature, strconv.FormatInt(timestamp, 10), body, time.Minute)
+	require.EqualError(t, err, ErrInvalidSignature.Error())
+
+	err = Verify(secret, signature, strconv.FormatInt(timestamp, 10), []byte(`{}`), time.Minute)
+	require.EqualError(t, err, ErrInvalidSignature.Error())
+}
+
+func TestVerifyExpiredTimestamp(t *testing.T) {
+	secret := utils.RandomString(32)
+	body := []byte(`{"event":"transfer.received"}`)
+	timestamp := time.Now().Add(-time.Hour).Unix()
+	signature := Sign(secret, timestamp, body)
+
+	err := Verify(secret, signature, strconv.FormatInt(timestamp, 10), body, time.Minute)
+	require.EqualError(t, err, ErrInvalidSignature.Error())
+}
+
+func TestBackoff(t *testing.T) {
+	base := 30 * time.Second
+
+	require.Equal(t, base, Backoff(base, 1))
+	require.Equal(t, 2*base, Backoff(base, 2))
+	require.Equal(t, 8*base, Backoff(base, 4))
+	require.Equal(t, maxBackoff, Backoff(base, 20))
+}
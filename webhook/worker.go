@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	batchSize      = 20
+	maxBackoff     = time.Hour
+	requestTimeout = 10 * time.Second
+	// leaseDuration hides a claimed delivery from other workers while it is being sent
+	leaseDuration = time.Minute
+	// deliveryTimeout bounds a delivery started before the shutdown: the request and recording its result
+	deliveryTimeout = requestTimeout + 5*time.Second
+)
+
+// Worker sends the pending deliveries, retrying failures with exponential backoff
+// until maxAttempts is reached. Then the delivery is moved to the dead letter state
+type Worker struct {
+	store        db.Store
+	client       *http.Client
+	maxAttempts  int32
+	backoffBase  time.Duration
+	pollInterval time.Duration
+}
+
+func NewWorker(store db.Store, maxAttempts int32, backoffBase, pollInterval time.Duration) *Worker {
+	return &Worker{
+		store:        store,
+		client:       newClient(),
+		maxAttempts:  maxAttempts,
+		backoffBase:  backoffBase,
+		pollInterval: pollInterval,
+	}
+}
+
+// Start polls for due deliveries until the context is cancelled. The delivery in flight then is finished,
+// the other claimed deliveries are sent again once their lease expires
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		w.processDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) processDue(ctx context.Context) {
+	deliveries, err := w.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(leaseDuration),
+		BatchSize:  batchSize,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("cannot claim webhook deliveries")
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if err = w.deliver(ctx, delivery); err != nil {
+			log.Warn().Err(err).Int64("delivery_id", delivery.ID).Msg("webhook delivery failed")
+		}
+	}
+}
+
+// deliver sends the delivery and records the attempt, a shutdown doesn't interrupt it
+func (w *Worker) deliver(ctx context.Context, delivery db.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(detachedContext{parent: ctx}, deliveryTimeout)
+	defer cancel()
+
+	subscription, err := w.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("cannot get webhook subscription: %w", err)
+	}
+
+	code, sendErr := w.send(ctx, subscription, delivery)
+
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        StatusSucceeded,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+	if code > 0 {
+		arg.LastResponseCode = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+
+	if sendErr == nil {
+		arg.DeliveredAt = sql.NullTime{Time: time.Now(), Valid: true}
+	} else {
+		arg.LastError = sendErr.Error()
+		arg.Status = StatusPending
+		arg.NextAttemptAt = time.Now().Add(Backoff(w.backoffBase, arg.Attempts))
+		if arg.Attempts >= w.maxAttempts {
+			arg.Status = StatusDead
+		}
+	}
+
+	_, err = w.store.UpdateWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("cannot update webhook delivery: %w", err)
+	}
+
+	return sendErr
+}
+
+// send POSTs the signed payload and returns the response status code
+func (w *Worker) send(ctx context.Context, subscription db.WebhookSubscription, delivery db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	rsp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return rsp.StatusCode, fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+
+	return rsp.StatusCode, nil
+}
+
+// Backoff returns base * 2^(attempts-1), capped to maxBackoff
+func Backoff(base time.Duration, attempts int32) time.Duration {
+	delay := base
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// detachedContext keeps the values of its parent, such as the trace, but not its cancellation.
+// It's context.WithoutCancel, which needs go 1.21
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
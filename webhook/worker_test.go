@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/micaelapucciariello/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/micaelapucciariello/simplebank/db/mock"
+	db "github.com/micaelapucciariello/simplebank/db/sqlc"
+)
+
+func TestWorkerDeliver(t *testing.T) {
+	subscription := db.WebhookSubscription{
+		ID:         utils.RandomInt(1, 1000),
+		Owner:      utils.RandomOwner(),
+		EventTypes: []string{EventTransferReceived},
+		Secret:     utils.RandomString(32),
+		IsActive:   true,
+	}
+	delivery := db.WebhookDelivery{
+		ID:             utils.RandomInt(1, 1000),
+		SubscriptionID: subscription.ID,
+		EventType:      EventTransferReceived,
+		Payload:        []byte(`{"event":"transfer.received"}`),
+		Status:         StatusPending,
+	}
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		attempts     int32
+		expectStatus string
+	}{
+		{
+			name:         "delivered",
+			statusCode:   http.StatusOK,
+			expectStatus: StatusSucceeded,
+		},
+		{
+			name:         "failed delivery is retried",
+			statusCode:   http.StatusInternalServerError,
+			expectStatus: StatusPending,
+		},
+		{
+			name:         "last attempt goes to dead letter",
+			statusCode:   http.StatusInternalServerError,
+			attempts:     2,
+			expectStatus: StatusDead,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, EventTransferReceived, r.Header.Get(EventHeader))
+				require.Equal(t, strconv.FormatInt(delivery.ID, 10), r.Header.Get(DeliveryHeader))
+
+				err = Verify(subscription.Secret, r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader), body, time.Minute)
+				require.NoError(t, err)
+
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer receiver.Close()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+
+			sub := subscription
+			sub.Url = receiver.URL
+			d := delivery
+			d.Attempts = tc.attempts
+
+			store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(sub.ID)).
+				Times(1).
+				Return(sub, nil)
+			store.EXPECT().UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+					require.Equal(t, d.ID, arg.ID)
+					require.Equal(t, tc.expectStatus, arg.Status)
+					require.Equal(t, tc.attempts+1, arg.Attempts)
+					require.Equal(t, sql.NullInt32{Int32: int32(tc.statusCode), Valid: true}, arg.LastResponseCode)
+					require.Equal(t, tc.expectStatus == StatusSucceeded, arg.DeliveredAt.Valid)
+					return db.WebhookDelivery{}, nil
+				})
+
+			// the receiver listens on loopback, which the client of the worker refuses
+			worker := NewWorker(store, 3, time.Second, time.Second)
+			worker.client = receiver.Client()
+			err := worker.deliver(context.Background(), d)
+			if tc.expectStatus == StatusSucceeded {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestWorkerDeliverPrivateAddress(t *testing.T) {
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	subscription := db.WebhookSubscription{
+		ID:       utils.RandomInt(1, 1000),
+		Url:      receiver.URL,
+		Secret:   utils.RandomString(32),
+		IsActive: true,
+	}
+	delivery := db.WebhookDelivery{
+		ID:             utils.RandomInt(1, 1000),
+		SubscriptionID: subscription.ID,
+		EventType:      EventTransferReceived,
+		Payload:        []byte(`{"event":"transfer.received"}`),
+		Status:         StatusPending,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, StatusPending, arg.Status)
+			require.Contains(t, arg.LastError, ErrPrivateAddress.Error())
+			return db.WebhookDelivery{}, nil
+		})
+
+	worker := NewWorker(store, 3, time.Second, time.Second)
+	err := worker.deliver(context.Background(), delivery)
+	require.ErrorIs(t, err, ErrPrivateAddress)
+	require.False(t, called)
+}
+
+func TestWorkerShutdown(t *testing.T) {
+	subscription := db.WebhookSubscription{
+		ID:       utils.RandomInt(1, 1000),
+		Secret:   utils.RandomString(32),
+		IsActive: true,
+	}
+	delivery := db.WebhookDelivery{
+		ID:             utils.RandomInt(1, 1000),
+		SubscriptionID: subscription.ID,
+		EventType:      EventTransferReceived,
+		Payload:        []byte(`{"event":"transfer.received"}`),
+		Status:         StatusPending,
+	}
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+	subscription.Url = receiver.URL
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// no new batch is claimed once the worker is stopping
+	store.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+
+	// the delivery in flight is sent and recorded although its context was cancelled
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.NoError(t, ctx.Err())
+			require.Equal(t, StatusSucceeded, arg.Status)
+			return db.WebhookDelivery{}, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	worker := NewWorker(store, 3, time.Second, time.Second)
+	worker.client = receiver.Client()
+	worker.Start(ctx)
+	require.NoError(t, worker.deliver(ctx, delivery))
+}